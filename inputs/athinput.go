@@ -0,0 +1,298 @@
+package inputs
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+//----------------------------------------------------------------------------------------
+//! \fn error ParameterInput.LoadFromAthinput(str []byte)
+//! \brief Load input parameters from a string in the native Athena++ athinput format
+//!
+//! The format is the same as Athena++: a block starts with "<block_name>", parameters
+//! are given by "name = value # comment", a value ending with '&' is continued on the
+//! next line, lines starting with '#' are comments and "<par_end>" stops the parsing.
+//! A line "#include file" includes another input file, relative to the current directory.
+//! A value "[a, b, ...]" whose elements have the same type is an array, where a string
+//! element may be quoted, e.g. if it contains a comma. A quoted value "..." is a string,
+//! which may contain '#' and end with '&', and keeps e.g. "false" from being a boolean
+//! or "nx1*2" from being an expression (see ParameterInput.EvaluateExpressions).
+//! Like LoadFromByte, nothing is changed if any error is found.
+
+func (this *ParameterInput) LoadFromAthinput(str []byte) error {
+	return this.loadBytes(parseAthinput, str)
+}
+
+// It's a private function. It parses an athinput input, returning the blocks, the line
+// number of each parameter keyed by "block/name" and the files to include.
+func parseAthinput(str []byte) (*inputBlocks, map[string]int, []string, error) {
+	temp := &inputBlocks{}
+	lines := make(map[string]int)
+	var includes []string
+	var block_name, para_name, pending string // pending is the value being continued
+	continuation := false
+
+	scanner := bufio.NewScanner(bytes.NewReader(str))
+	line_num := 0
+	for scanner.Scan() {
+		line_num++
+		line := strings.TrimSpace(scanner.Text())
+
+		if continuation {
+			value, more := splitValue(line)
+			pending += value
+			continuation = more
+			if !continuation {
+				setAthinputValue(temp.add(block_name), para_name, pending)
+			}
+			continue
+		}
+		if strings.HasPrefix(line, "#include") {
+			file := strings.Trim(strings.TrimSpace(line[len("#include"):]), "\"")
+			if len(file) == 0 {
+				return nil, nil, nil, &InputSyntaxError{Line: line_num, Msg: "Empty file name to include."}
+			}
+			includes = append(includes, file)
+			continue
+		}
+		// Skip blank lines and comment lines.
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+		// Stop reading at the end of parameters.
+		if strings.HasPrefix(line, "<par_end>") {
+			break
+		}
+
+		if line[0] == '<' {
+			end := strings.IndexByte(line, '>')
+			if end == -1 {
+				return nil, nil, nil, &InputSyntaxError{Line: line_num, Msg: fmt.Sprintf("Block name '%s' isn't properly ended.", line)}
+			}
+			block_name = strings.TrimSpace(line[1:end])
+			if len(block_name) == 0 {
+				return nil, nil, nil, &InputSyntaxError{Line: line_num, Msg: "Empty block name."}
+			}
+			temp.add(block_name)
+			continue
+		}
+
+		if block_name == "" {
+			return nil, nil, nil, &InputSyntaxError{Line: line_num, Msg: fmt.Sprintf("Parameter '%s' isn't in any block.", line)}
+		}
+		eq := strings.IndexByte(line, '=')
+		if eq == -1 {
+			return nil, nil, nil, &InputSyntaxError{Line: line_num, Msg: fmt.Sprintf("'%s' isn't in the form 'name = value'.", line)}
+		}
+		para_name = strings.TrimSpace(line[:eq])
+		if len(para_name) == 0 {
+			return nil, nil, nil, &InputSyntaxError{Line: line_num, Msg: "Empty parameter name."}
+		}
+		value, more := splitValue(line[eq+1:])
+		continuation = more
+		lines[block_name+"/"+para_name] = line_num
+		if continuation {
+			pending = value
+		} else {
+			setAthinputValue(temp.add(block_name), para_name, value)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, nil, err
+	}
+	if continuation {
+		return nil, nil, nil, &InputSyntaxError{Line: line_num,
+			Msg: fmt.Sprintf("Parameter %s in block %s isn't continued.", para_name, block_name)}
+	}
+	return temp, lines, includes, nil
+}
+
+// It's a private function. It sets a parameter to the value of its text, recording
+// whether a string is quoted.
+func setAthinputValue(block *inputLine, para_name string, text string) {
+	value := parseValue(text)
+	if str, ok := value.(string); ok && str != text && text[0] == '"' {
+		block.setQuoted(para_name, str)
+	} else {
+		block.setLiteral(para_name, value, text)
+	}
+}
+
+// It's a private function. It strips the comment from a value, and reports whether the
+// value is continued on the next line. A '#' in a quoted string doesn't start a comment.
+func splitValue(str string) (string, bool) {
+	quoted := false
+	for i := 0; i < len(str); i++ {
+		if quoted && str[i] == '\\' {
+			i++
+		} else if str[i] == '"' {
+			quoted = !quoted
+		} else if !quoted && str[i] == '#' {
+			str = str[:i]
+			break
+		}
+	}
+	str = strings.TrimSpace(str)
+	if strings.HasSuffix(str, "&") {
+		return strings.TrimSpace(str[:len(str)-1]), true
+	}
+	return str, false
+}
+
+// It's a private function. Values are stored as float64 (the same as JSON) if they are
+// numbers, as bool if they are "true" or "false", and as string otherwise, where a quoted
+// string is unquoted.
+func parseValue(str string) interface{} {
+	switch str {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+	if len(str) >= 2 && str[0] == '[' && str[len(str)-1] == ']' {
+		if array, ok := parseArray(str[1 : len(str)-1]); ok {
+			return array
+		}
+		return str
+	}
+	if len(str) >= 2 && str[0] == '"' {
+		if prefix, err := strconv.QuotedPrefix(str); err == nil && prefix == str {
+			value, _ := strconv.Unquote(str)
+			return value
+		}
+	}
+	if value, err := strconv.ParseFloat(str, 64); err == nil {
+		// "inf" and "nan" are kept as strings because JSON can't hold them.
+		if !math.IsInf(value, 0) && !math.IsNaN(value) {
+			return value
+		}
+	}
+	return str
+}
+
+// It's a private function. It parses the elements of an array "[a, b, ...]", where a
+// string element may be quoted, e.g. if it contains a comma. It returns false if the
+// elements aren't of the same type.
+func parseArray(str string) (interface{}, bool) {
+	array := []interface{}{}
+	str = strings.TrimSpace(str)
+	for len(str) > 0 {
+		var element interface{}
+		end := strings.IndexByte(str, ',')
+		if str[0] == '"' {
+			prefix, err := strconv.QuotedPrefix(str)
+			if err != nil {
+				return nil, false
+			}
+			element, _ = strconv.Unquote(prefix)
+			end = len(prefix)
+			if rest := strings.TrimSpace(str[end:]); len(rest) > 0 && rest[0] != ',' {
+				return nil, false
+			}
+			if end = strings.IndexByte(str[end:], ','); end != -1 {
+				end += len(prefix)
+			}
+		}
+		if end == -1 {
+			end = len(str)
+		}
+		if element == nil {
+			element = parseValue(strings.TrimSpace(str[:end]))
+		}
+		array = append(array, element)
+		if end == len(str) {
+			break
+		}
+		str = strings.TrimSpace(str[end+1:])
+	}
+	if len(array) == 0 {
+		return array, true
+	}
+	return normalizeArray(array)
+}
+
+//----------------------------------------------------------------------------------------
+//! \fn string ParameterInput.AthinputDump()
+//! \brief output entire InputBlock/InputLine hierarchy in the athinput format
+//!
+//! The output can be loaded again by LoadFromAthinput. Blocks and parameters are in the
+//! order of the input, numbers and arrays keep the text they are written with, and names
+//! in each block are aligned as Athena++ does, so that a dump is dumped again unchanged.
+
+func (this *ParameterInput) AthinputDump() string {
+	this.rwlock.RLock()
+	defer this.rwlock.RUnlock()
+	return this.dumpAthinput(false)
+}
+
+// It's a private function. It writes the blocks in the athinput format, where the source
+// of each value is given as a comment if with_sources is set. The caller must hold the
+// lock.
+func (this *ParameterInput) dumpAthinput(with_sources bool) string {
+	var builder strings.Builder
+	builder.WriteString("#------------------------- PAR_DUMP -------------------------\n")
+	for _, block_name := range this.input_block.names {
+		block, _ := this.input_block.get(block_name)
+		max_len, max_value_len := 0, 0
+		for _, para_name := range block.names {
+			if len(para_name) > max_len {
+				max_len = len(para_name)
+			}
+			if value_len := len(athinputValue(block, para_name)); value_len > max_value_len {
+				max_value_len = value_len
+			}
+		}
+		fmt.Fprintf(&builder, "<%s>\n", block_name)
+		for _, para_name := range block.names {
+			line := fmt.Sprintf("%-*s = %-*s", max_len, para_name, max_value_len, athinputValue(block, para_name))
+			if source, ok := this.sources[block_name+"/"+para_name]; ok && with_sources {
+				line += "  # " + source
+			}
+			builder.WriteString(strings.TrimRight(line, " ") + "\n")
+		}
+	}
+	builder.WriteString("#------------------------- PAR_DUMP -------------------------\n")
+	builder.WriteString("<par_end>\n")
+	return builder.String()
+}
+
+// It's a private function. It's the athinput text of a parameter, which is the text in
+// the input for a number. A string is quoted if it's quoted in the input or if it would
+// be read back differently.
+func athinputValue(block *inputLine, para_name string) string {
+	if literal, ok := block.literals[para_name]; ok {
+		return literal
+	}
+	para, _ := block.get(para_name)
+	if text, ok := para.(string); ok && (block.quoted[para_name] || parseValue(text) != text || text != strings.TrimSpace(text) ||
+		text == "" || strings.Contains(text, "#") || strings.HasSuffix(text, "&")) {
+		return strconv.Quote(text)
+	}
+	return formatValue(para)
+}
+
+// It's a private function. It's the inverse of parseValue.
+func formatValue(para interface{}) string {
+	if elements, ok := arrayElements(para); ok {
+		texts := make([]string, len(elements))
+		for i, element := range elements {
+			texts[i] = formatValue(element)
+			// Quote a string which would be parsed differently.
+			if text, ok := element.(string); ok && (parseValue(text) != text || text != strings.TrimSpace(text) ||
+				text == "" || strings.ContainsAny(text, ",\"#")) {
+				texts[i] = strconv.Quote(text)
+			}
+		}
+		return "[" + strings.Join(texts, ", ") + "]"
+	}
+	switch value := para.(type) {
+	case float64:
+		return strconv.FormatFloat(value, 'g', -1, 64)
+	default:
+		return fmt.Sprint(value)
+	}
+}
@@ -0,0 +1,97 @@
+package inputs
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAthinputDumpRoundTrip(t *testing.T) {
+	values := map[string]interface{}{
+		"comment":      "run#1",
+		"boolean":      "false",
+		"continuation": "x &",
+		"number":       "12",
+		"blank":        " pad",
+		"empty":        "",
+		"quote":        `say "hi" #`,
+		"array_text":   "[1, 2]",
+		"array":        []string{"a#b", "c,d"},
+		"plain":        "plain",
+		"real":         1.5,
+		"flag":         true,
+	}
+	var pin ParameterInput
+	for name, value := range values {
+		pin.SetParameter("problem", name, value)
+	}
+	dump := pin.AthinputDump()
+	var loaded ParameterInput
+	if err := loaded.LoadFromAthinput([]byte(dump)); err != nil {
+		t.Fatalf("the dump isn't loaded: %v\n%s", err, dump)
+	}
+	for name := range values {
+		want, _ := pin.GetParameter("problem", name)
+		got, err := loaded.GetParameter("problem", name)
+		if err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("%s = %#v, %v, want %#v\n%s", name, got, err, want, dump)
+		}
+	}
+}
+
+func TestAthinputQuotedValues(t *testing.T) {
+	tests := []struct {
+		line string
+		want interface{}
+	}{
+		{`a = "run#1"  # comment`, "run#1"},
+		{`a = run#1`, "run"},
+		{`a = "false"`, "false"},
+		{`a = false`, false},
+		{`a = "1e3"`, "1e3"},
+		{`a = "x &"`, "x &"},
+		{`a = "tab\tend"`, "tab\tend"},
+		{`a = "unterminated`, `"unterminated`},
+		{`a = "two" "parts"`, `"two" "parts"`},
+	}
+	for _, test := range tests {
+		var pin ParameterInput
+		if err := pin.LoadFromAthinput([]byte("<problem>\n" + test.line + "\n")); err != nil {
+			t.Errorf("%s: %v", test.line, err)
+			continue
+		}
+		if got, _ := pin.GetParameter("problem", "a"); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %#v, want %#v", test.line, got, test.want)
+		}
+	}
+}
+
+func TestAthinputDumpUnchanged(t *testing.T) {
+	deck := "<a>\nreal  = 1.0\narr   = [1, 2.0]\nnames = [\"x,y\", z]\nlabel = \"n*2\"\n"
+	var pin ParameterInput
+	if err := pin.LoadFromAthinput([]byte(deck)); err != nil {
+		t.Fatal(err)
+	}
+	dump := pin.AthinputDump()
+	if !strings.Contains(dump, deck) {
+		t.Errorf("the dump doesn't contain the deck:\n%s", dump)
+	}
+	var loaded ParameterInput
+	if err := loaded.LoadFromAthinput([]byte(dump)); err != nil {
+		t.Fatal(err)
+	}
+	if again := loaded.AthinputDump(); again != dump {
+		t.Errorf("the dump is changed by loading it again:\n%s\n%s", dump, again)
+	}
+	if with_sources := pin.AthinputDumpWithSources(); !strings.Contains(with_sources, "arr   = [1, 2.0]    # line 3") {
+		t.Errorf("the source of a/arr isn't given:\n%s", with_sources)
+	}
+
+	var json_pin ParameterInput
+	if err := json_pin.LoadFromByte([]byte(`{"a": {"arr": [1, 2.0]}}`)); err != nil {
+		t.Fatal(err)
+	}
+	if dump := json_pin.ParameterDump(); !strings.Contains(dump, `"arr": [1, 2.0]`) {
+		t.Errorf("the text of a/arr isn't kept:\n%s", dump)
+	}
+}
@@ -0,0 +1,91 @@
+package inputs
+
+import (
+	"fmt"
+	"strings"
+)
+
+//----------------------------------------------------------------------------------------
+// Parameters can be overridden by the environment and the command line, after the restart
+// and input files are loaded. The precedence is, from low to high:
+//   restart file < input file < environment (GOTHENA_block__name) < command line
+
+const ENV_PREFIX = "GOTHENA_"
+
+// It's a private type. It's a change of a single parameter.
+type modification struct {
+	block_name, para_name string
+	value                 interface{}
+	source                string
+}
+
+//----------------------------------------------------------------------------------------
+//! \fn error ParameterInput.ModifyFromCmdline(args []string)
+//! \brief parse commandline for changes to input parameters
+//!
+//! Each argument must be in the form "block/name=value". The type of the value is
+//! inferred the same way as in athinput files. Like Athena++, the block must already
+//! exist while the parameter is inserted if it does not exist. Nothing is changed if any
+//! argument is illegal.
+
+func (this *ParameterInput) ModifyFromCmdline(args []string) error {
+	var mods []modification
+	for _, arg := range args {
+		slash := strings.IndexByte(arg, '/')
+		eq := strings.IndexByte(arg, '=')
+		if slash <= 0 || eq == -1 || eq < slash {
+			return &InputSyntaxError{File: "command line", Msg: fmt.Sprintf("'%s' isn't in the form 'block/name=value'.", arg)}
+		}
+		para_name := strings.TrimSpace(arg[slash+1 : eq])
+		if len(para_name) == 0 {
+			return &InputSyntaxError{File: "command line", Msg: fmt.Sprintf("Empty parameter name in '%s'.", arg)}
+		}
+		mods = append(mods, modification{strings.TrimSpace(arg[:slash]), para_name,
+			parseValue(strings.TrimSpace(arg[eq+1:])), SOURCE_CMDLINE})
+	}
+	return this.modify(mods)
+}
+
+//----------------------------------------------------------------------------------------
+//! \fn error ParameterInput.ModifyFromEnvironment(environ []string)
+//! \brief parse environment variables for changes to input parameters
+//!
+//! Variables in the form "GOTHENA_block__name=value" (a double underscore separates the
+//! block and the name) change block/name, e.g. GOTHENA_time__tlim=2.0. Other variables,
+//! including those with the prefix but without a block and a name (e.g. GOTHENA_HOME),
+//! are ignored, and environ is usually os.Environ(). Otherwise the rules are the same as
+//! ModifyFromCmdline.
+
+func (this *ParameterInput) ModifyFromEnvironment(environ []string) error {
+	var mods []modification
+	for _, env := range environ {
+		eq := strings.IndexByte(env, '=')
+		if !strings.HasPrefix(env, ENV_PREFIX) || eq == -1 {
+			continue
+		}
+		key := env[len(ENV_PREFIX):eq]
+		sep := strings.Index(key, "__")
+		if sep <= 0 || sep+2 == len(key) {
+			continue
+		}
+		mods = append(mods, modification{key[:sep], key[sep+2:],
+			parseValue(strings.TrimSpace(env[eq+1:])), "env " + env[:eq]})
+	}
+	return this.modify(mods)
+}
+
+// It's a private function. It checks that all the blocks exist, then applies mods.
+func (this *ParameterInput) modify(mods []modification) error {
+	this.rwlock.Lock()
+	defer this.rwlock.Unlock()
+	for _, mod := range mods {
+		if _, ok := this.input_block.get(mod.block_name); !ok {
+			return &ParameterNotFoundError{Block: mod.block_name}
+		}
+	}
+	for _, mod := range mods {
+		this.setValue(mod.block_name, mod.para_name, mod.value)
+		this.setSource(mod.block_name, mod.para_name, mod.source)
+	}
+	return nil
+}
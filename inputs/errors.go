@@ -0,0 +1,89 @@
+package inputs
+
+import (
+	"errors"
+	"fmt"
+)
+
+//----------------------------------------------------------------------------------------
+// Errors returned by ParameterInput. All of them match ErrInput with errors.Is, so the
+// caller can tell bad input from other failures, while errors.As gives the details.
+
+var ErrInput = errors.New("input error")
+
+//----------------------------------------------------------------------------------------
+//! \struct ParameterNotFoundError
+//! \brief the block, or the parameter in the block, doesn't exist. Name is empty if the
+//! block itself is missing.
+
+type ParameterNotFoundError struct {
+	Block, Name string
+}
+
+func (this *ParameterNotFoundError) Error() string {
+	if this.Name == "" {
+		return fmt.Sprintf("Get Parameter Error: Block %s doesn't exist.", this.Block)
+	}
+	return fmt.Sprintf("Get Parameter Error: %s isn't in block %s.", this.Name, this.Block)
+}
+
+func (this *ParameterNotFoundError) Is(target error) bool { return target == ErrInput }
+
+//----------------------------------------------------------------------------------------
+//! \struct ParameterTypeError
+//! \brief the parameter exists, but its value isn't of the expected type
+
+type ParameterTypeError struct {
+	Block, Name string
+	Value       interface{}
+	Expected    string // e.g. "an integer"
+}
+
+func (this *ParameterTypeError) Error() string {
+	return fmt.Sprintf("Parameter Type Error: %s in block %s isn't %s (%#v).",
+		this.Name, this.Block, this.Expected, this.Value)
+}
+
+func (this *ParameterTypeError) Is(target error) bool { return target == ErrInput }
+
+//----------------------------------------------------------------------------------------
+//! \struct ParameterValueError
+//! \brief the parameter has the right type, but its value isn't allowed
+
+type ParameterValueError struct {
+	Block, Name string
+	Value       interface{}
+	Reason      string // e.g. "must be positive"
+}
+
+func (this *ParameterValueError) Error() string {
+	return fmt.Sprintf("Parameter Value Error: %s in block %s %s (%#v).",
+		this.Name, this.Block, this.Reason, this.Value)
+}
+
+func (this *ParameterValueError) Is(target error) bool { return target == ErrInput }
+
+//----------------------------------------------------------------------------------------
+//! \struct InputSyntaxError
+//! \brief the input can't be parsed. File is empty if the input isn't read from a file,
+//! and Line is 0 if it's unknown.
+
+type InputSyntaxError struct {
+	File string
+	Line int
+	Msg  string
+}
+
+func (this *InputSyntaxError) Error() string {
+	switch {
+	case this.File != "" && this.Line > 0:
+		return fmt.Sprintf("Input Syntax Error: %s:%d: %s", this.File, this.Line, this.Msg)
+	case this.File != "":
+		return fmt.Sprintf("Input Syntax Error: %s: %s", this.File, this.Msg)
+	case this.Line > 0:
+		return fmt.Sprintf("Input Syntax Error: Line %d: %s", this.Line, this.Msg)
+	}
+	return "Input Syntax Error: " + this.Msg
+}
+
+func (this *InputSyntaxError) Is(target error) bool { return target == ErrInput }
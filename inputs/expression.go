@@ -0,0 +1,410 @@
+package inputs
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+//----------------------------------------------------------------------------------------
+// Expressions in parameter values. A string value containing "${" is an expression, where
+// "${block/name}" (or "${name}" in the same block) is replaced by the value of another
+// parameter. If the result is an arithmetic expression of numbers, it's evaluated to a
+// number, e.g. "1.5*${mesh/x1max}" or "sqrt(${hydro/gamma})". Otherwise the references
+// are substituted as text, e.g. "${job/problem_id}_hires".
+//
+// A bare name is a parameter of the same block, so that a string value without "${" is
+// evaluated too if it calls a function or applies an operator to a bare name, e.g.
+// "sqrt(2)", "sqrt(gamma)" or "nx1*2". Arithmetic of numbers only (e.g. "1-2", "2024-1-5"
+// or "1.2-3"), a single name (e.g. "gamma") and a name which isn't a numeric parameter of
+// the block (e.g. "run-1") keep the string unchanged. A quoted athinput value, the
+// informational <comment> block and the values of a restart file are never evaluated.
+//
+// Operators are + - * / ^ and parentheses. Functions are sqrt, exp, log, log10, sin, cos,
+// tan, abs, floor, ceil, pow, min and max, and the constant is pi.
+
+//----------------------------------------------------------------------------------------
+//! \struct ExpressionError
+//! \brief the expression of a parameter can't be evaluated
+
+type ExpressionError struct {
+	Block, Name, Expr, Msg string
+}
+
+func (this *ExpressionError) Error() string {
+	return fmt.Sprintf("Expression Error: %s in block %s (%q): %s", this.Name, this.Block, this.Expr, this.Msg)
+}
+
+func (this *ExpressionError) Is(target error) bool { return target == ErrInput }
+
+//----------------------------------------------------------------------------------------
+//! \fn error ParameterInput.EvaluateExpressions()
+//! \brief replaces every expression by its value
+//!
+//! Parameters referenced by an expression are evaluated first, and a cycle of references
+//! is an error. It's called once all the inputs are loaded, so that an expression sees
+//! the final values of the parameters it references. Nothing is changed on error.
+
+func (this *ParameterInput) EvaluateExpressions() error {
+	this.rwlock.Lock()
+	defer this.rwlock.Unlock()
+	eval := expressionEvaluator{pin: this, values: make(map[string]interface{}),
+		visiting: make(map[string]bool)}
+	for _, block_name := range this.input_block.names {
+		block, _ := this.input_block.get(block_name)
+		for _, para_name := range block.names {
+			if _, err := eval.value(block_name, para_name, nil); err != nil {
+				return err
+			}
+		}
+	}
+	for key, value := range eval.values {
+		slash := strings.IndexByte(key, '/')
+		block, _ := this.input_block.get(key[:slash])
+		block.set(key[slash+1:], value)
+	}
+	return nil
+}
+
+// It's a private function. It's an expression string with references.
+func isExpression(para interface{}) bool {
+	str, ok := para.(string)
+	return ok && strings.Contains(str, "${")
+}
+
+// It's a private type. values holds evaluated expressions, keyed by "block/name".
+type expressionEvaluator struct {
+	pin      *ParameterInput
+	values   map[string]interface{}
+	visiting map[string]bool
+}
+
+// It's a private function. It returns the (evaluated) value of block/name, where chain is
+// the list of expressions referencing it, used for reporting cycles.
+func (this *expressionEvaluator) value(block_name string, para_name string, chain []string) (interface{}, error) {
+	key := block_name + "/" + para_name
+	if value, ok := this.values[key]; ok {
+		return value, nil
+	}
+	para, err := this.pin.getValue(block_name, para_name)
+	expr, ok := para.(string)
+	if err != nil || !ok || block_name == "comment" {
+		return para, err
+	}
+	// A value loaded from a restart file is already evaluated.
+	block, _ := this.pin.input_block.get(block_name)
+	if block.quoted[para_name] || strings.HasPrefix(this.pin.sources[key], "restart ") {
+		return para, nil
+	}
+	chain = append(chain, key)
+	if this.visiting[key] {
+		return nil, &ExpressionError{block_name, para_name, expr,
+			"Cycle of references: " + strings.Join(chain, " -> ")}
+	}
+	this.visiting[key] = true
+	defer delete(this.visiting, key)
+
+	resolve := func(ref string) (interface{}, error) {
+		ref_block, ref_name := block_name, ref
+		if slash := strings.IndexByte(ref, '/'); slash != -1 {
+			ref_block, ref_name = ref[:slash], ref[slash+1:]
+		}
+		value, err := this.value(ref_block, ref_name, chain)
+		var not_found *ParameterNotFoundError
+		if errors.As(err, &not_found) {
+			return nil, &ExpressionError{block_name, para_name, expr,
+				fmt.Sprintf("Referenced parameter %s/%s doesn't exist", ref_block, ref_name)}
+		}
+		return value, err
+	}
+	// A bare name which isn't another parameter means that the string isn't an expression,
+	// e.g. "run-1" for the parameter run.
+	resolve_bare := func(name string) (interface{}, error) {
+		if _, err := this.pin.getValue(block_name, name); err != nil || name == para_name {
+			return nil, notArithmetic{}
+		}
+		return this.value(block_name, name, chain)
+	}
+
+	parser := expressionParser{str: expr, resolve: resolve, resolve_bare: resolve_bare}
+	var result interface{}
+	result, err = parser.parse()
+	if _, ok := err.(notArithmetic); ok {
+		if !isExpression(expr) {
+			return expr, nil
+		}
+		result, err = interpolate(expr, resolve)
+	}
+	if err != nil {
+		var expr_err *ExpressionError
+		if !errors.As(err, &expr_err) {
+			err = &ExpressionError{block_name, para_name, expr, err.Error()}
+		}
+		return nil, err
+	}
+	this.values[key] = result
+	return result, nil
+}
+
+// It's a private function. It substitutes the references in str as text.
+func interpolate(str string, resolve func(string) (interface{}, error)) (interface{}, error) {
+	var builder strings.Builder
+	for {
+		start := strings.Index(str, "${")
+		if start == -1 {
+			builder.WriteString(str)
+			return builder.String(), nil
+		}
+		end := strings.IndexByte(str[start:], '}')
+		if end == -1 {
+			return nil, errors.New("Unterminated ${")
+		}
+		value, err := resolve(str[start+2 : start+end])
+		if err != nil {
+			return nil, err
+		}
+		builder.WriteString(str[:start])
+		builder.WriteString(formatValue(value))
+		str = str[start+end+1:]
+	}
+}
+
+// It's a private type, returned by the parser if the string isn't an arithmetic
+// expression, so it's substituted as text instead.
+type notArithmetic struct{}
+
+func (notArithmetic) Error() string { return "not an arithmetic expression" }
+
+// It's a private type. It's a recursive descent parser evaluating while parsing, which
+// records what is parsed to tell an expression from a string.
+type expressionParser struct {
+	str          string
+	pos          int
+	resolve      func(string) (interface{}, error)
+	resolve_bare func(string) (interface{}, error)
+	operated     bool // an operator or parentheses
+	referenced   bool // a reference "${...}" or a function call
+	bare         bool // a bare name
+}
+
+var expressionFunctions = map[string]func(args []float64) (float64, error){
+	"sqrt":  func1(math.Sqrt),
+	"exp":   func1(math.Exp),
+	"log":   func1(math.Log),
+	"log10": func1(math.Log10),
+	"sin":   func1(math.Sin),
+	"cos":   func1(math.Cos),
+	"tan":   func1(math.Tan),
+	"abs":   func1(math.Abs),
+	"floor": func1(math.Floor),
+	"ceil":  func1(math.Ceil),
+	"pow":   func2(math.Pow),
+	"min":   func2(math.Min),
+	"max":   func2(math.Max),
+}
+
+func func1(f func(float64) float64) func([]float64) (float64, error) {
+	return func(args []float64) (float64, error) {
+		if len(args) != 1 {
+			return 0, fmt.Errorf("Expected 1 argument, got %d", len(args))
+		}
+		return f(args[0]), nil
+	}
+}
+
+func func2(f func(float64, float64) float64) func([]float64) (float64, error) {
+	return func(args []float64) (float64, error) {
+		if len(args) != 2 {
+			return 0, fmt.Errorf("Expected 2 arguments, got %d", len(args))
+		}
+		return f(args[0], args[1]), nil
+	}
+}
+
+func (this *expressionParser) parse() (interface{}, error) {
+	value, err := this.expr()
+	if err != nil {
+		return nil, err
+	}
+	this.skipSpace()
+	if this.pos != len(this.str) {
+		return nil, notArithmetic{}
+	}
+	// Arithmetic of numbers only (e.g. "2024-1-5") and a single name are kept as strings.
+	if !this.referenced && !(this.bare && this.operated) {
+		return nil, notArithmetic{}
+	}
+	if math.IsInf(value, 0) || math.IsNaN(value) {
+		return nil, errors.New("The result isn't a finite number")
+	}
+	return value, nil
+}
+
+func (this *expressionParser) skipSpace() {
+	for this.pos < len(this.str) && (this.str[this.pos] == ' ' || this.str[this.pos] == '\t') {
+		this.pos++
+	}
+}
+
+// It's a private function. It consumes c if it's the next non-blank character.
+func (this *expressionParser) accept(c byte) bool {
+	this.skipSpace()
+	if this.pos < len(this.str) && this.str[this.pos] == c {
+		this.pos++
+		this.operated = true
+		return true
+	}
+	return false
+}
+
+// expr := term (('+'|'-') term)*
+func (this *expressionParser) expr() (float64, error) {
+	value, err := this.term()
+	for err == nil {
+		if this.accept('+') {
+			var rhs float64
+			rhs, err = this.term()
+			value += rhs
+		} else if this.accept('-') {
+			var rhs float64
+			rhs, err = this.term()
+			value -= rhs
+		} else {
+			break
+		}
+	}
+	return value, err
+}
+
+// term := unary (('*'|'/') unary)*
+func (this *expressionParser) term() (float64, error) {
+	value, err := this.unary()
+	for err == nil {
+		if this.accept('*') {
+			var rhs float64
+			rhs, err = this.unary()
+			value *= rhs
+		} else if this.accept('/') {
+			var rhs float64
+			rhs, err = this.unary()
+			value /= rhs
+		} else {
+			break
+		}
+	}
+	return value, err
+}
+
+// unary := ('-'|'+') unary | primary ('^' unary)?
+func (this *expressionParser) unary() (float64, error) {
+	if this.accept('-') {
+		value, err := this.unary()
+		return -value, err
+	}
+	if this.accept('+') {
+		return this.unary()
+	}
+	value, err := this.primary()
+	if err == nil && this.accept('^') {
+		var exponent float64
+		exponent, err = this.unary()
+		value = math.Pow(value, exponent)
+	}
+	return value, err
+}
+
+// primary := number | '(' expr ')' | '${' ref '}' | function '(' expr (',' expr)* ')' | pi
+func (this *expressionParser) primary() (float64, error) {
+	this.skipSpace()
+	if this.accept('(') {
+		value, err := this.expr()
+		if err == nil && !this.accept(')') {
+			err = notArithmetic{}
+		}
+		return value, err
+	}
+	if strings.HasPrefix(this.str[this.pos:], "${") {
+		end := strings.IndexByte(this.str[this.pos:], '}')
+		if end == -1 {
+			return 0, notArithmetic{}
+		}
+		ref := this.str[this.pos+2 : this.pos+end]
+		this.pos += end + 1
+		value, err := this.resolve(ref)
+		if err != nil {
+			return 0, err
+		}
+		number, ok := value.(float64)
+		if !ok {
+			return 0, notArithmetic{}
+		}
+		this.referenced = true
+		return number, nil
+	}
+
+	start := this.pos
+	for this.pos < len(this.str) && isIdentChar(this.str[this.pos]) {
+		this.pos++
+	}
+	word := this.str[start:this.pos]
+	if len(word) == 0 {
+		return 0, notArithmetic{}
+	}
+	if word[0] >= '0' && word[0] <= '9' || word[0] == '.' {
+		// Take the sign of an exponent, e.g. 1e-3.
+		if (word[len(word)-1] == 'e' || word[len(word)-1] == 'E') && this.pos < len(this.str) &&
+			(this.str[this.pos] == '-' || this.str[this.pos] == '+') {
+			this.pos++
+			for this.pos < len(this.str) && isIdentChar(this.str[this.pos]) {
+				this.pos++
+			}
+			word = this.str[start:this.pos]
+		}
+		value, err := strconv.ParseFloat(word, 64)
+		if err != nil {
+			return 0, notArithmetic{}
+		}
+		return value, nil
+	}
+	if word == "pi" {
+		return math.Pi, nil
+	}
+	function, ok := expressionFunctions[word]
+	if !ok {
+		value, err := this.resolve_bare(word)
+		if err != nil {
+			return 0, err
+		}
+		number, ok := value.(float64)
+		if !ok {
+			return 0, notArithmetic{}
+		}
+		this.bare = true
+		return number, nil
+	}
+	if !this.accept('(') {
+		return 0, notArithmetic{}
+	}
+	var args []float64
+	for {
+		arg, err := this.expr()
+		if err != nil {
+			return 0, err
+		}
+		args = append(args, arg)
+		if this.accept(')') {
+			break
+		}
+		if !this.accept(',') {
+			return 0, notArithmetic{}
+		}
+	}
+	this.referenced = true
+	return function(args)
+}
+
+func isIdentChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '.'
+}
@@ -0,0 +1,188 @@
+package inputs
+
+import (
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// It loads an athinput text and evaluates its expressions.
+func evaluateAthinput(text string) (*ParameterInput, error) {
+	pin := &ParameterInput{}
+	if err := pin.LoadFromAthinput([]byte(text)); err != nil {
+		return nil, err
+	}
+	return pin, pin.EvaluateExpressions()
+}
+
+func TestEvaluateExpressions(t *testing.T) {
+	deck := `
+<mesh>
+nx1   = 64
+nx2   = nx1*2
+x1max = 2.0
+x2max = 1.5*${x1max}
+x3max = ${mesh/x2max} / 2
+<hydro>
+gamma = 1.4
+cs    = sqrt(gamma)
+root2 = sqrt(2)
+ratio = (gamma - 1)^2 + max(${mesh/nx1}, 3) - pi
+neg   = -${mesh/x1max}
+exp   = 1e-3 * ${cs}^2
+minus = -gamma
+<job>
+problem_id = blast
+basename   = ${problem_id}_${mesh/nx1}
+run        = run-1
+date       = 2024-01-05
+alone      = pi
+name       = gamma
+version    = 1.2.3
+id         = "1-2"
+day        = 2024-1-5
+ver        = 1.2-3
+label      = "nx1*2"
+negative   = -1
+twopi      = 2*pi
+ref        = "${problem_id}"
+<comment>
+problem = blast wave with ${gamma}
+<mesh>
+nx3   = "nx1*2"
+`
+	pin, err := evaluateAthinput(deck)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		block, name string
+		want        interface{}
+	}{
+		{"mesh", "nx2", 128.0},
+		{"mesh", "x2max", 3.0},
+		{"mesh", "x3max", 1.5},
+		{"hydro", "cs", math.Sqrt(1.4)},
+		{"hydro", "root2", math.Sqrt(2)},
+		{"hydro", "ratio", (1.4-1)*(1.4-1) + 64 - math.Pi},
+		{"hydro", "neg", -2.0},
+		{"hydro", "exp", 1.4e-3},
+		{"hydro", "minus", -1.4},
+		{"job", "basename", "blast_64"},
+		{"job", "run", "run-1"},
+		{"job", "date", "2024-01-05"},
+		{"job", "alone", "pi"},
+		{"job", "name", "gamma"},
+		{"job", "version", "1.2.3"},
+		{"job", "id", "1-2"},
+		{"job", "day", "2024-1-5"},
+		{"job", "ver", "1.2-3"},
+		{"job", "label", "nx1*2"},
+		{"job", "negative", -1.0},
+		{"job", "twopi", "2*pi"},
+		{"job", "ref", "${problem_id}"},
+		{"comment", "problem", "blast wave with ${gamma}"},
+		{"mesh", "nx3", "nx1*2"},
+	}
+	for _, test := range tests {
+		got, err := pin.GetParameter(test.block, test.name)
+		if number, ok := test.want.(float64); ok {
+			if value, ok := got.(float64); ok && math.Abs(value-number) < 1e-12 {
+				continue
+			}
+		} else if reflect.DeepEqual(got, test.want) {
+			continue
+		}
+		t.Errorf("%s/%s = %#v, %v, want %#v", test.block, test.name, got, err, test.want)
+	}
+	if nx2, err := pin.GetInteger("mesh", "nx2"); err != nil || nx2 != 128 {
+		t.Errorf("GetInteger(mesh, nx2) = %d, %v", nx2, err)
+	}
+}
+
+func TestExpressionErrors(t *testing.T) {
+	tests := []struct {
+		deck, msg string
+	}{
+		{"<a>\nx = ${y}+1\ny = ${x}*2\n", "Cycle of references"},
+		{"<a>\nx = y+1\ny = x*2\n", "Cycle of references"},
+		{"<a>\nx = ${x}\n", "Cycle of references"},
+		{"<a>\nx = ${b/y}*2\n", "Referenced parameter b/y doesn't exist"},
+		{"<a>\nx = ${y}*2\n", "Referenced parameter a/y doesn't exist"},
+		{"<a>\ny = 0\nx = 1/y\n", "isn't a finite number"},
+		{"<a>\nx = sqrt(-1)\n", "isn't a finite number"},
+		{"<a>\ny = 0\nx = log(y)\n", "isn't a finite number"},
+		{"<a>\nx = pow(2)\n", "Expected 2 arguments"},
+		{"<a>\nx = ${y\n", "Unterminated ${"},
+	}
+	for _, test := range tests {
+		_, err := evaluateAthinput(test.deck)
+		var expr_err *ExpressionError
+		if !errors.As(err, &expr_err) || !strings.Contains(err.Error(), test.msg) {
+			t.Errorf("%q: got %v, want an ExpressionError with %q", test.deck, err, test.msg)
+		}
+		if err != nil && !errors.Is(err, ErrInput) {
+			t.Errorf("%q: %v isn't an input error", test.deck, err)
+		}
+	}
+}
+
+func TestExpressionsUnchangedOnError(t *testing.T) {
+	pin := &ParameterInput{}
+	pin.LoadFromAthinput([]byte("<a>\nx = sqrt(4)\ny = ${z}\n"))
+	if err := pin.EvaluateExpressions(); err == nil {
+		t.Fatal("no error for a missing reference")
+	}
+	if x, _ := pin.GetParameter("a", "x"); x != "sqrt(4)" {
+		t.Errorf("x = %#v after an error, want it unchanged", x)
+	}
+}
+
+func TestJSONStringsUnevaluated(t *testing.T) {
+	pin := &ParameterInput{}
+	err := pin.LoadFromByte([]byte(`{"a": {"n": 4, "neg": "-1", "id": "1-2", "twice": "n*2"}}`))
+	if err == nil {
+		err = pin.EvaluateExpressions()
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]interface{}{"neg": "-1", "id": "1-2", "twice": 8.0} {
+		if got, _ := pin.GetParameter("a", name); got != want {
+			t.Errorf("a/%s = %#v, want %#v", name, got, want)
+		}
+	}
+}
+
+func TestQuotedAndRestartValuesUnevaluated(t *testing.T) {
+	pin, err := evaluateAthinput("<a>\nn = 4\nlabel = \"n*2\"\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dumped, err := evaluateAthinput(pin.AthinputDump())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if label, _ := dumped.GetParameter("a", "label"); label != "n*2" {
+		t.Errorf("a/label = %#v after dumping, want \"n*2\"", label)
+	}
+
+	filename := filepath.Join(t.TempDir(), "run.rst")
+	if err := os.WriteFile(filename, []byte(`{"a": {"n": 4, "label": "n*2"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	restart := &ParameterInput{}
+	if err := restart.LoadFromRestartFile(filename); err != nil {
+		t.Fatal(err)
+	}
+	if err := restart.EvaluateExpressions(); err != nil {
+		t.Fatal(err)
+	}
+	if label, _ := restart.GetParameter("a", "label"); label != "n*2" {
+		t.Errorf("a/label = %#v from a restart file, want \"n*2\"", label)
+	}
+}
@@ -0,0 +1,171 @@
+package inputs
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+)
+
+//----------------------------------------------------------------------------------------
+// Formats of input files. All of them are loaded into the same blocks of parameters, and
+// a value must be a bool, a string, a finite number or an array of elements of the same
+// one of these types in any format.
+
+const (
+	FORMAT_AUTO     = "" // by the extension of the file, see LoadFromFile
+	FORMAT_JSON     = "json"
+	FORMAT_ATHINPUT = "athinput"
+	FORMAT_TOML     = "toml"
+	FORMAT_YAML     = "yaml"
+)
+
+// It's a private type. It parses an input, returning the blocks, the line number of each
+// parameter keyed by "block/name" and the files to include.
+type parseFunc func(str []byte) (*inputBlocks, map[string]int, []string, error)
+
+var parsers = map[string]parseFunc{
+	FORMAT_JSON:     parseJSON,
+	FORMAT_ATHINPUT: parseAthinput,
+	FORMAT_TOML:     parseTOML,
+	FORMAT_YAML:     parseYAML,
+}
+
+//----------------------------------------------------------------------------------------
+//! \fn bool IsFormat(format string)
+//! \brief whether format is one of the FORMAT_* constants
+
+func IsFormat(format string) bool {
+	_, ok := parsers[format]
+	return ok || format == FORMAT_AUTO
+}
+
+// It's a private function. It guesses the format of a file by its extension, then by its
+// first non-blank character outside comments.
+func inputFormat(filename string, str []byte) string {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".json":
+		return FORMAT_JSON
+	case ".toml":
+		return FORMAT_TOML
+	case ".yaml", ".yml":
+		return FORMAT_YAML
+	}
+	if stripped, err := stripJSONComments(str); err == nil {
+		trimmed := bytes.TrimSpace(stripped)
+		if len(trimmed) > 0 && trimmed[0] == '{' {
+			return FORMAT_JSON
+		}
+	}
+	return FORMAT_ATHINPUT
+}
+
+// It's a private function. It loads an input given as a string, where included files are
+// relative to the current directory. Nothing is changed if any error is found.
+func (this *ParameterInput) loadBytes(parse parseFunc, str []byte) error {
+	temp, lines, includes, err := parse(str)
+	if err != nil {
+		return err
+	}
+	data, err := loadIncludes(newInputData(temp, lines, ""), includes, ".", nil)
+	if err != nil {
+		return err
+	}
+	// If no error exists, add the input parameter to this. Seperating the check and
+	// write is to make sure changes will not applied until no error is found.
+	this.mergeBlocks(data)
+	return nil
+}
+
+// It's a private function. It checks the type of a value read from an input, converting
+// an array to []float64, []string or []bool. An empty array is kept as []interface{}.
+func normalizeParameter(block_name string, para_name string, para interface{}) (interface{}, error) {
+	switch value := para.(type) {
+	case bool, string, float64:
+		return para, nil
+	case []interface{}:
+		if len(value) == 0 {
+			return value, nil
+		}
+		if result, ok := normalizeArray(value); ok {
+			return result, nil
+		}
+	}
+	return nil, &ParameterTypeError{block_name, para_name, para,
+		"a bool, string, number or array of elements of one of these types"}
+}
+
+// It's a private function. It converts an array whose elements have the same scalar type.
+func normalizeArray(array []interface{}) (interface{}, bool) {
+	ok := true
+	switch array[0].(type) {
+	case float64:
+		result := make([]float64, len(array))
+		for i, element := range array {
+			result[i], ok = element.(float64)
+			if !ok {
+				return nil, false
+			}
+		}
+		return result, true
+	case string:
+		result := make([]string, len(array))
+		for i, element := range array {
+			result[i], ok = element.(string)
+			if !ok {
+				return nil, false
+			}
+		}
+		return result, true
+	case bool:
+		result := make([]bool, len(array))
+		for i, element := range array {
+			result[i], ok = element.(bool)
+			if !ok {
+				return nil, false
+			}
+		}
+		return result, true
+	}
+	return nil, false
+}
+
+// It's a private function. It returns the elements of an array parameter, or false if
+// para isn't an array.
+func arrayElements(para interface{}) ([]interface{}, bool) {
+	var result []interface{}
+	switch value := para.(type) {
+	case []interface{}:
+		return value, true
+	case []float64:
+		for _, element := range value {
+			result = append(result, element)
+		}
+	case []string:
+		for _, element := range value {
+			result = append(result, element)
+		}
+	case []bool:
+		for _, element := range value {
+			result = append(result, element)
+		}
+	default:
+		return nil, false
+	}
+	return result, true
+}
+
+// It's a private function. It copies an array parameter, so that the caller can't change
+// the stored one. Other values are immutable.
+func copyValue(para interface{}) interface{} {
+	switch value := para.(type) {
+	case []interface{}:
+		return append([]interface{}{}, value...)
+	case []float64:
+		return append([]float64{}, value...)
+	case []string:
+		return append([]string{}, value...)
+	case []bool:
+		return append([]bool{}, value...)
+	}
+	return para
+}
@@ -0,0 +1,132 @@
+package inputs
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+//----------------------------------------------------------------------------------------
+// Input files can include other files, by "@include": ["file", ...] at the top level of
+// JSON, or by "#include file" lines in athinput. Included files are loaded in the listed
+// order, then the parameters of the including file override them, wherever the include
+// is written. Relative paths are resolved from the directory of the including file.
+
+const INCLUDE_KEY = "@include"
+
+// It's a private function. It parses a file and all files it includes, where stack is the
+// chain of files including it, used for detecting cycles.
+func loadFile(filename string, format string, stack []string) (*inputData, error) {
+	abs_name, err := filepath.Abs(filename)
+	if err != nil {
+		return nil, err
+	}
+	for i, name := range stack {
+		if name == abs_name {
+			chain := append(append([]string{}, stack[i:]...), abs_name)
+			return nil, &InputSyntaxError{File: filename,
+				Msg: "Include cycle: " + strings.Join(chain, " -> ")}
+		}
+	}
+
+	str, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	if format == FORMAT_AUTO {
+		format = inputFormat(filename, str)
+	}
+	parse, ok := parsers[format]
+	if !ok {
+		return nil, fmt.Errorf("Unknown input format %q", format)
+	}
+	temp, lines, includes, err := parse(str)
+	if err != nil {
+		var syntax_err *InputSyntaxError
+		if errors.As(err, &syntax_err) && syntax_err.File == "" {
+			syntax_err.File = filename
+		}
+		return nil, err
+	}
+	return loadIncludes(newInputData(temp, lines, filename), includes, filepath.Dir(filename),
+		append(stack, abs_name))
+}
+
+// It's a private function. It loads the included files, in order, below temp.
+func loadIncludes(temp *inputData, includes []string, dir string, stack []string) (*inputData, error) {
+	if len(includes) == 0 {
+		return temp, nil
+	}
+	result := &inputData{&inputBlocks{}, make(map[string]string)}
+	for _, include := range includes {
+		if !filepath.IsAbs(include) {
+			include = filepath.Join(dir, include)
+		}
+		included, err := loadFile(include, FORMAT_AUTO, stack)
+		if err != nil {
+			if len(stack) > 0 && !errors.Is(err, ErrInput) {
+				err = fmt.Errorf("%s: %w", stack[len(stack)-1], err)
+			}
+			return nil, err
+		}
+		mergeInto(result, included)
+	}
+	mergeInto(result, temp)
+	return result, nil
+}
+
+// It's a private type. It's a parsed input before being merged, where sources holds the
+// source of each parameter keyed by "block/name" (missing if unknown).
+type inputData struct {
+	blocks  *inputBlocks
+	sources map[string]string
+}
+
+// It's a private function. All parameters in blocks come from the file source, at the
+// lines given by "block/name". The source is "file:line", or "line N" without file name.
+func newInputData(blocks *inputBlocks, lines map[string]int, source string) *inputData {
+	this := &inputData{blocks, make(map[string]string)}
+	for _, block_name := range blocks.names {
+		block, _ := blocks.get(block_name)
+		for _, para_name := range block.names {
+			key := block_name + "/" + para_name
+			line, ok := lines[key]
+			switch {
+			case source != "" && ok:
+				this.sources[key] = fmt.Sprintf("%s:%d", source, line)
+			case source != "":
+				this.sources[key] = source
+			case ok:
+				this.sources[key] = fmt.Sprintf("line %d", line)
+			}
+		}
+	}
+	return this
+}
+
+// It's a private function. Parameters in src replace the ones in dst, keeping their
+// position, and new blocks and parameters are appended in the order of src.
+func mergeInto(dst *inputData, src *inputData) {
+	for _, block_name := range src.blocks.names {
+		block, _ := src.blocks.get(block_name)
+		dst_block := dst.blocks.add(block_name)
+		for _, para_name := range block.names {
+			key := block_name + "/" + para_name
+			para, _ := block.get(para_name)
+			if literal, ok := block.literals[para_name]; ok {
+				dst_block.setLiteral(para_name, para, literal)
+			} else if block.quoted[para_name] {
+				dst_block.setQuoted(para_name, para.(string))
+			} else {
+				dst_block.set(para_name, para)
+			}
+			if source, ok := src.sources[key]; ok {
+				dst.sources[key] = source
+			} else {
+				delete(dst.sources, key)
+			}
+		}
+	}
+}
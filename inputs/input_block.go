@@ -0,0 +1,97 @@
+package inputs
+
+import (
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//----------------------------------------------------------------------------------------
+// Blocks and parameters keep the order in which they are first inserted, so that dumps
+// and restart files list them in the same order as the input deck. Numbers and arrays also
+// keep the text they are written with (e.g. "1.0", "1e-3" or "[1, 2.0]") until they are
+// changed, so that a deck in the dump format is dumped again byte-for-byte.
+
+// It's a private type. It's the parameters of a block in insertion order.
+type inputLine struct {
+	names    []string
+	values   map[string]interface{}
+	literals map[string]string // text of numbers and arrays as written in the input
+	quoted   map[string]bool   // strings quoted in an athinput input
+}
+
+func newInputLine() *inputLine {
+	return &inputLine{values: make(map[string]interface{}), literals: make(map[string]string),
+		quoted: make(map[string]bool)}
+}
+
+func (this *inputLine) get(para_name string) (interface{}, bool) {
+	para, ok := this.values[para_name]
+	return para, ok
+}
+
+// It's a private function. A new parameter is appended, while an existing one keeps its
+// position. The literal text (or quoting) is dropped since the value may be different.
+func (this *inputLine) set(para_name string, value interface{}) {
+	if _, ok := this.values[para_name]; !ok {
+		this.names = append(this.names, para_name)
+	}
+	this.values[para_name] = value
+	delete(this.literals, para_name)
+	delete(this.quoted, para_name)
+}
+
+// It's a private function. It's the same as set, keeping the text of a number read from
+// the input if it's read back as the same number (e.g. not "0x1F" in TOML), and the text
+// of an array on a single line if it's read back as the same array in athinput.
+func (this *inputLine) setLiteral(para_name string, value interface{}, literal string) {
+	this.set(para_name, value)
+	if number, ok := value.(float64); ok {
+		if parsed, err := strconv.ParseFloat(literal, 64); err == nil && parsed == number {
+			this.literals[para_name] = literal
+		}
+	} else if _, ok := arrayElements(value); ok && !strings.ContainsAny(literal, "\r\n") &&
+		reflect.DeepEqual(parseValue(literal), value) {
+		this.literals[para_name] = literal
+	}
+}
+
+// It's a private function. It's the same as set for a string quoted in the input, which
+// is never evaluated as an expression and is quoted again in the dump.
+func (this *inputLine) setQuoted(para_name string, value string) {
+	this.set(para_name, value)
+	this.quoted[para_name] = true
+}
+
+// It's a private type. It's the blocks in insertion order. The zero value is empty.
+type inputBlocks struct {
+	names  []string
+	blocks map[string]*inputLine
+}
+
+func (this *inputBlocks) get(block_name string) (*inputLine, bool) {
+	block, ok := this.blocks[block_name]
+	return block, ok
+}
+
+// It's a private function. It returns the block, which is appended if it doesn't exist.
+func (this *inputBlocks) add(block_name string) *inputLine {
+	if block, ok := this.blocks[block_name]; ok {
+		return block
+	}
+	if this.blocks == nil {
+		this.blocks = make(map[string]*inputLine)
+	}
+	block := newInputLine()
+	this.blocks[block_name] = block
+	this.names = append(this.names, block_name)
+	return block
+}
+
+// It's a private function. It returns a sorted copy of names.
+func sortedNames(names []string) []string {
+	result := append([]string{}, names...)
+	sort.Strings(result)
+	return result
+}
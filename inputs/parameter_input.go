@@ -1,16 +1,25 @@
 package inputs
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math"
+	"sort"
+	"strings"
 	"sync"
 )
 
-type inputLine map[string]interface{}
 type ParameterInput struct {
 	rwlock      sync.RWMutex
-	input_block map[string]inputLine
+	input_block inputBlocks
+	sources     map[string]string // where each parameter comes from, keyed by "block/name"
+	// Usage of parameters, keyed by "block/name". They have their own lock because they
+	// are written by getters holding the read lock.
+	usage_lock sync.Mutex
+	accessed   map[string]bool
+	defaulted  map[string]interface{} // the inserted default values
 }
 
 //----------------------------------------------------------------------------------------
@@ -18,45 +27,177 @@ type ParameterInput struct {
 //! \brief Load input parameters from a string in bytes format
 //!
 //! If a parameters already exist, the value are replaced. If not, the parameter and the value
-//! will be inserted into the original input block. Comments aren't allowed.
+//! will be inserted into the original input block. Comments "// ..." and "/* ... */" are
+//! allowed outside strings, as JSONC. Files listed in the top-level "@include" array are
+//! loaded first, relative to the current directory.
 
 func (this *ParameterInput) LoadFromByte(str []byte) error {
-	temp := make(map[string]inputLine)
-	if err := json.Unmarshal(str, &temp); err != nil {
-		return err
+	return this.loadBytes(parseJSON, str)
+}
+
+// It's a private function. It parses a JSON input, returning the blocks, the line number
+// of each parameter keyed by "block/name" and the files to include. The input is walked
+// token by token to keep the order of blocks and parameters.
+func parseJSON(str []byte) (*inputBlocks, map[string]int, []string, error) {
+	str, err := stripJSONComments(str)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	// Check the syntax first, so that the walk below only sees valid JSON.
+	if err := json.Unmarshal(str, &map[string]json.RawMessage{}); err != nil {
+		return nil, nil, nil, jsonSyntaxError(str, err)
 	}
-	// Check whether the input value format is legal.
-	for block_name, block := range temp {
-		for para_name, para := range block {
-			switch para.(type) {
-			case bool, string, float64, int:
-			default:
-				return fmt.Errorf("Load Parameter Error: Parameter %s in block %s is illegal.", para_name, block_name)
+	temp := &inputBlocks{}
+	lines := make(map[string]int)
+	var includes []string
+	decoder := json.NewDecoder(bytes.NewReader(str))
+	decoder.Token() // '{'
+	for decoder.More() {
+		token, _ := decoder.Token()
+		block_name := token.(string)
+		if block_name == INCLUDE_KEY {
+			if err := decoder.Decode(&includes); err != nil {
+				return nil, nil, nil, &InputSyntaxError{Msg: INCLUDE_KEY + " must be an array of file names."}
+			}
+			continue
+		}
+		if delim, _ := decoder.Token(); delim != json.Delim('{') {
+			return nil, nil, nil, &InputSyntaxError{Msg: fmt.Sprintf("Block %s isn't an object.", block_name)}
+		}
+		block := temp.add(block_name)
+		for decoder.More() {
+			token, _ := decoder.Token()
+			para_name := token.(string)
+			lines[block_name+"/"+para_name] = bytes.Count(str[:decoder.InputOffset()], []byte("\n")) + 1
+			var raw json.RawMessage
+			decoder.Decode(&raw)
+			var para interface{}
+			json.Unmarshal(raw, &para)
+			// Check whether the input value format is legal.
+			if para, err = normalizeParameter(block_name, para_name, para); err != nil {
+				return nil, nil, nil, err
 			}
+			block.setLiteral(para_name, para, string(raw))
 		}
+		decoder.Token() // '}'
 	}
-	// If no error exists, add the input parameter to this. Seperating the check and
-	// write is to make sure changes will not applied until no error is found.
-	this.rwlock.Lock()
-	defer this.rwlock.Unlock()
-	// If the input_block hasn't been initialized, just use the temp.
-	if len(this.input_block) == 0 {
-		this.input_block = temp
-		return nil
-	}
-	for block_name, block := range temp {
-		_, ok := this.input_block[block_name]
-		if ok {
-			for para_name, para := range block {
-				this.input_block[block_name][para_name] = para
+	return temp, lines, includes, nil
+}
+
+// It's a private function. It replaces the comments outside strings by spaces, keeping
+// the newlines, so that the offsets and the line numbers are unchanged.
+func stripJSONComments(str []byte) ([]byte, error) {
+	result := append([]byte{}, str...)
+	in_string := false
+	for i := 0; i < len(result); i++ {
+		switch {
+		case in_string:
+			if result[i] == '\\' {
+				i++
+			} else if result[i] == '"' {
+				in_string = false
 			}
-		} else {
-			this.input_block[block_name] = block
+		case result[i] == '"':
+			in_string = true
+		case bytes.HasPrefix(result[i:], []byte("//")):
+			for ; i < len(result) && result[i] != '\n'; i++ {
+				result[i] = ' '
+			}
+		case bytes.HasPrefix(result[i:], []byte("/*")):
+			end := bytes.Index(result[i+2:], []byte("*/"))
+			if end == -1 {
+				return nil, &InputSyntaxError{Line: bytes.Count(str[:i], []byte("\n")) + 1,
+					Msg: "The comment isn't properly ended."}
+			}
+			for end += i + 4; i < end; i++ {
+				if result[i] != '\n' {
+					result[i] = ' '
+				}
+			}
+			i--
 		}
 	}
+	return result, nil
+}
+
+//----------------------------------------------------------------------------------------
+//! \fn error ParameterInput.LoadFromFile(filename string)
+//! \brief Load input parameters from a file in JSON, athinput, TOML or YAML format
+//!
+//! Files ending with ".json", or whose first non-blank character (outside comments) is
+//! '{', are parsed as JSON. Files ending with ".toml" are parsed as TOML, and files
+//! ending with ".yaml" or ".yml" as YAML. Anything else is parsed as a native Athena++
+//! athinput file. Included files are resolved relative to the directory of the file
+//! including them.
+
+func (this *ParameterInput) LoadFromFile(filename string) error {
+	return this.LoadFromFileAs(filename, FORMAT_AUTO)
+}
+
+//----------------------------------------------------------------------------------------
+//! \fn error ParameterInput.LoadFromFileAs(filename string, format string)
+//! \brief Load input parameters from a file in the given format (one of FORMAT_*)
+//!
+//! The format of included files is still guessed by LoadFromFile.
+
+func (this *ParameterInput) LoadFromFileAs(filename string, format string) error {
+	data, err := loadFile(filename, format, nil)
+	if err != nil {
+		return err
+	}
+	this.mergeBlocks(data)
 	return nil
 }
 
+//----------------------------------------------------------------------------------------
+//! \fn error ParameterInput.LoadFromRestartFile(filename string)
+//! \brief Load input parameters from a restart file
+//!
+//! It's the same as LoadFromFile, except that the source of each parameter is marked as
+//! "restart file:line".
+
+func (this *ParameterInput) LoadFromRestartFile(filename string) error {
+	data, err := loadFile(filename, FORMAT_AUTO, nil)
+	if err != nil {
+		return err
+	}
+	for key, source := range data.sources {
+		data.sources[key] = "restart " + source
+	}
+	this.mergeBlocks(data)
+	return nil
+}
+
+// It's a private function. It converts the error from encoding/json, whose position is
+// given by the byte offset, to InputSyntaxError with the line number.
+func jsonSyntaxError(str []byte, err error) error {
+	var offset int64
+	var syntax_err *json.SyntaxError
+	var type_err *json.UnmarshalTypeError
+	if errors.As(err, &syntax_err) {
+		offset = syntax_err.Offset
+	} else if errors.As(err, &type_err) {
+		offset = type_err.Offset
+	} else {
+		return &InputSyntaxError{Msg: err.Error()}
+	}
+	if offset > int64(len(str)) {
+		offset = int64(len(str))
+	}
+	line := bytes.Count(str[:offset], []byte("\n")) + 1
+	return &InputSyntaxError{Line: line, Msg: err.Error()}
+}
+
+// It's a private function. Parameters in data replace the existing ones.
+func (this *ParameterInput) mergeBlocks(data *inputData) {
+	this.rwlock.Lock()
+	defer this.rwlock.Unlock()
+	if this.sources == nil {
+		this.sources = make(map[string]string)
+	}
+	mergeInto(&inputData{&this.input_block, this.sources}, data)
+}
+
 //----------------------------------------------------------------------------------------
 //! \fn (interface{}, error) ParameterInput.GetParameter(block string, name string)
 //! \brief returns parameter value stored in block/name; return error if it does not exist
@@ -64,31 +205,352 @@ func (this *ParameterInput) LoadFromByte(str []byte) error {
 func (this *ParameterInput) GetParameter(block_name string, para_name string) (interface{}, error) {
 	this.rwlock.RLock()
 	defer this.rwlock.RUnlock()
-	if input_line, ok := this.input_block[block_name]; ok {
-		if para, ok := input_line[para_name]; ok {
-			// Deepcopy for secure
-			var result interface{}
-			temp, _ := json.Marshal(para)
-			json.Unmarshal(temp, result)
-			return result, nil
+	para, err := this.getValue(block_name, para_name)
+	this.markAccessed(block_name, para_name)
+	if err != nil {
+		return nil, err
+	}
+	// Deepcopy for secure
+	return copyValue(para), nil
+}
+
+// It's a private function. The caller must hold the lock.
+func (this *ParameterInput) getValue(block_name string, para_name string) (interface{}, error) {
+	if input_line, ok := this.input_block.get(block_name); ok {
+		if para, ok := input_line.get(para_name); ok {
+			return para, nil
 		} else {
-			return nil, fmt.Errorf("Get Parameter Error: %s isn't in block %s.", para_name, block_name)
+			return nil, &ParameterNotFoundError{block_name, para_name}
 		}
 	}
-	return nil, fmt.Errorf("Get Parameter Error: Block %s doesn't exist.", block_name)
+	return nil, &ParameterNotFoundError{Block: block_name}
+}
+
+//----------------------------------------------------------------------------------------
+//! \fn (int, error) ParameterInput.GetInteger(block string, name string)
+//! \brief returns integer value stored in block/name; return error if it does not exist
+//! or isn't an integer (a real number with a fractional part is not an integer)
+
+func (this *ParameterInput) GetInteger(block_name string, para_name string) (int, error) {
+	this.rwlock.RLock()
+	defer this.rwlock.RUnlock()
+	para, err := this.getValue(block_name, para_name)
+	this.markAccessed(block_name, para_name)
+	if err != nil {
+		return 0, err
+	}
+	return toInteger(block_name, para_name, para)
+}
+
+//----------------------------------------------------------------------------------------
+//! \fn (float64, error) ParameterInput.GetReal(block string, name string)
+//! \brief returns real value stored in block/name; return error if it does not exist or
+//! isn't a number
+
+func (this *ParameterInput) GetReal(block_name string, para_name string) (float64, error) {
+	this.rwlock.RLock()
+	defer this.rwlock.RUnlock()
+	para, err := this.getValue(block_name, para_name)
+	this.markAccessed(block_name, para_name)
+	if err != nil {
+		return 0, err
+	}
+	return toReal(block_name, para_name, para)
+}
+
+//----------------------------------------------------------------------------------------
+//! \fn (bool, error) ParameterInput.GetBoolean(block string, name string)
+//! \brief returns boolean value stored in block/name; return error if it does not exist
+//! or isn't a boolean
+
+func (this *ParameterInput) GetBoolean(block_name string, para_name string) (bool, error) {
+	this.rwlock.RLock()
+	defer this.rwlock.RUnlock()
+	para, err := this.getValue(block_name, para_name)
+	this.markAccessed(block_name, para_name)
+	if err != nil {
+		return false, err
+	}
+	return toBoolean(block_name, para_name, para)
+}
+
+//----------------------------------------------------------------------------------------
+//! \fn (string, error) ParameterInput.GetString(block string, name string)
+//! \brief returns string value stored in block/name; return error if it does not exist
+//! or isn't a string
+
+func (this *ParameterInput) GetString(block_name string, para_name string) (string, error) {
+	this.rwlock.RLock()
+	defer this.rwlock.RUnlock()
+	para, err := this.getValue(block_name, para_name)
+	this.markAccessed(block_name, para_name)
+	if err != nil {
+		return "", err
+	}
+	return toString(block_name, para_name, para)
+}
+
+//----------------------------------------------------------------------------------------
+//! \fn ([]int, error) ParameterInput.GetIntegerArray(block string, name string)
+//! \brief returns a copy of the array stored in block/name; return error if it does not
+//! exist or isn't an array of integers
+
+func (this *ParameterInput) GetIntegerArray(block_name string, para_name string) ([]int, error) {
+	this.rwlock.RLock()
+	defer this.rwlock.RUnlock()
+	para, err := this.getValue(block_name, para_name)
+	this.markAccessed(block_name, para_name)
+	if err != nil {
+		return nil, err
+	}
+	return toIntegerArray(block_name, para_name, para)
+}
+
+//----------------------------------------------------------------------------------------
+//! \fn ([]float64, error) ParameterInput.GetRealArray(block string, name string)
+//! \brief returns a copy of the array stored in block/name; return error if it does not
+//! exist or isn't an array of numbers
+
+func (this *ParameterInput) GetRealArray(block_name string, para_name string) ([]float64, error) {
+	this.rwlock.RLock()
+	defer this.rwlock.RUnlock()
+	para, err := this.getValue(block_name, para_name)
+	this.markAccessed(block_name, para_name)
+	if err != nil {
+		return nil, err
+	}
+	return toRealArray(block_name, para_name, para)
+}
+
+//----------------------------------------------------------------------------------------
+//! \fn ([]bool, error) ParameterInput.GetBooleanArray(block string, name string)
+//! \brief returns a copy of the array stored in block/name; return error if it does not
+//! exist or isn't an array of booleans
+
+func (this *ParameterInput) GetBooleanArray(block_name string, para_name string) ([]bool, error) {
+	this.rwlock.RLock()
+	defer this.rwlock.RUnlock()
+	para, err := this.getValue(block_name, para_name)
+	this.markAccessed(block_name, para_name)
+	if err != nil {
+		return nil, err
+	}
+	return toBooleanArray(block_name, para_name, para)
+}
+
+//----------------------------------------------------------------------------------------
+//! \fn ([]string, error) ParameterInput.GetStringArray(block string, name string)
+//! \brief returns a copy of the array stored in block/name; return error if it does not
+//! exist or isn't an array of strings
+
+func (this *ParameterInput) GetStringArray(block_name string, para_name string) ([]string, error) {
+	this.rwlock.RLock()
+	defer this.rwlock.RUnlock()
+	para, err := this.getValue(block_name, para_name)
+	this.markAccessed(block_name, para_name)
+	if err != nil {
+		return nil, err
+	}
+	return toStringArray(block_name, para_name, para)
+}
+
+//----------------------------------------------------------------------------------------
+//! \fn (int, error) ParameterInput.GetOrAddInteger(block string, name string, def int)
+//! \brief returns integer value stored in block/name, or the default value (which is
+//! inserted) if it does not exist; return error if it isn't an integer
+
+func (this *ParameterInput) GetOrAddInteger(block_name string, para_name string, def int) (int, error) {
+	this.rwlock.Lock()
+	defer this.rwlock.Unlock()
+	para, err := this.getValue(block_name, para_name)
+	this.markAccessed(block_name, para_name)
+	if err != nil {
+		this.setValue(block_name, para_name, float64(def))
+		this.setSource(block_name, para_name, SOURCE_DEFAULT)
+		this.markDefaulted(block_name, para_name, float64(def))
+		return def, nil
+	}
+	return toInteger(block_name, para_name, para)
+}
+
+//----------------------------------------------------------------------------------------
+//! \fn (float64, error) ParameterInput.GetOrAddReal(block string, name string, def float64)
+//! \brief returns real value stored in block/name, or the default value (which is
+//! inserted) if it does not exist; return error if it isn't a number
+
+func (this *ParameterInput) GetOrAddReal(block_name string, para_name string, def float64) (float64, error) {
+	this.rwlock.Lock()
+	defer this.rwlock.Unlock()
+	para, err := this.getValue(block_name, para_name)
+	this.markAccessed(block_name, para_name)
+	if err != nil {
+		this.setValue(block_name, para_name, def)
+		this.setSource(block_name, para_name, SOURCE_DEFAULT)
+		this.markDefaulted(block_name, para_name, def)
+		return def, nil
+	}
+	return toReal(block_name, para_name, para)
+}
+
+//----------------------------------------------------------------------------------------
+//! \fn (bool, error) ParameterInput.GetOrAddBoolean(block string, name string, def bool)
+//! \brief returns boolean value stored in block/name, or the default value (which is
+//! inserted) if it does not exist; return error if it isn't a boolean
+
+func (this *ParameterInput) GetOrAddBoolean(block_name string, para_name string, def bool) (bool, error) {
+	this.rwlock.Lock()
+	defer this.rwlock.Unlock()
+	para, err := this.getValue(block_name, para_name)
+	this.markAccessed(block_name, para_name)
+	if err != nil {
+		this.setValue(block_name, para_name, def)
+		this.setSource(block_name, para_name, SOURCE_DEFAULT)
+		this.markDefaulted(block_name, para_name, def)
+		return def, nil
+	}
+	return toBoolean(block_name, para_name, para)
+}
+
+//----------------------------------------------------------------------------------------
+//! \fn (string, error) ParameterInput.GetOrAddString(block string, name string, def string)
+//! \brief returns string value stored in block/name, or the default value (which is
+//! inserted) if it does not exist; return error if it isn't a string
+
+func (this *ParameterInput) GetOrAddString(block_name string, para_name string, def string) (string, error) {
+	this.rwlock.Lock()
+	defer this.rwlock.Unlock()
+	para, err := this.getValue(block_name, para_name)
+	this.markAccessed(block_name, para_name)
+	if err != nil {
+		this.setValue(block_name, para_name, def)
+		this.setSource(block_name, para_name, SOURCE_DEFAULT)
+		this.markDefaulted(block_name, para_name, def)
+		return def, nil
+	}
+	return toString(block_name, para_name, para)
+}
+
+// Private functions to convert the stored value. JSON doesn't distinguish integers from
+// real numbers, so an integer is a float64 without fractional part.
+func toInteger(block_name string, para_name string, para interface{}) (int, error) {
+	switch value := para.(type) {
+	case int:
+		return value, nil
+	case float64:
+		if value == math.Trunc(value) && math.Abs(value) <= 1<<53 {
+			return int(value), nil
+		}
+	}
+	return 0, &ParameterTypeError{block_name, para_name, para, "an integer"}
+}
+
+func toReal(block_name string, para_name string, para interface{}) (float64, error) {
+	switch value := para.(type) {
+	case int:
+		return float64(value), nil
+	case float64:
+		return value, nil
+	}
+	return 0, &ParameterTypeError{block_name, para_name, para, "a real number"}
+}
+
+func toBoolean(block_name string, para_name string, para interface{}) (bool, error) {
+	if value, ok := para.(bool); ok {
+		return value, nil
+	}
+	return false, &ParameterTypeError{block_name, para_name, para, "a boolean"}
+}
+
+func toString(block_name string, para_name string, para interface{}) (string, error) {
+	if value, ok := para.(string); ok {
+		return value, nil
+	}
+	return "", &ParameterTypeError{block_name, para_name, para, "a string"}
+}
+
+// Private functions to convert the stored arrays, returning copies. An empty array read
+// from the input has no type, so it's converted to any type.
+func toIntegerArray(block_name string, para_name string, para interface{}) ([]int, error) {
+	value, ok := para.([]float64)
+	if !ok && !isEmptyArray(para) {
+		return nil, &ParameterTypeError{block_name, para_name, para, "an array of integers"}
+	}
+	result := make([]int, len(value))
+	for i, element := range value {
+		var err error
+		if result[i], err = toInteger(block_name, para_name, element); err != nil {
+			return nil, &ParameterTypeError{block_name, para_name, para, "an array of integers"}
+		}
+	}
+	return result, nil
+}
+
+func toRealArray(block_name string, para_name string, para interface{}) ([]float64, error) {
+	if value, ok := para.([]float64); ok || isEmptyArray(para) {
+		return append([]float64{}, value...), nil
+	}
+	return nil, &ParameterTypeError{block_name, para_name, para, "an array of real numbers"}
+}
+
+func toBooleanArray(block_name string, para_name string, para interface{}) ([]bool, error) {
+	if value, ok := para.([]bool); ok || isEmptyArray(para) {
+		return append([]bool{}, value...), nil
+	}
+	return nil, &ParameterTypeError{block_name, para_name, para, "an array of booleans"}
+}
+
+func toStringArray(block_name string, para_name string, para interface{}) ([]string, error) {
+	if value, ok := para.([]string); ok || isEmptyArray(para) {
+		return append([]string{}, value...), nil
+	}
+	return nil, &ParameterTypeError{block_name, para_name, para, "an array of strings"}
+}
+
+func isEmptyArray(para interface{}) bool {
+	elements, ok := arrayElements(para)
+	return ok && len(elements) == 0
 }
 
 //----------------------------------------------------------------------------------------
 //! \fn ParameterInput.SetParameter(block string, name string, value interface{})
-//! \brief updates a parameter; creates it if it does not exist
+//! \brief updates a parameter; creates it if it does not exist. Its source is "run time".
 
 func (this *ParameterInput) SetParameter(block_name string, para_name string, value interface{}) {
 	this.rwlock.Lock()
 	defer this.rwlock.Unlock()
-	if _, ok := this.input_block[block_name]; !ok {
-		this.input_block[block_name] = make(inputLine)
+	this.setValue(block_name, para_name, value)
+	this.setSource(block_name, para_name, SOURCE_RUNTIME)
+}
+
+// It's a private function. The caller must hold the lock.
+func (this *ParameterInput) setValue(block_name string, para_name string, value interface{}) {
+	this.input_block.add(block_name).set(para_name, value)
+	delete(this.sources, block_name+"/"+para_name)
+}
+
+// It's a private function. The caller must hold the lock.
+func (this *ParameterInput) setSource(block_name string, para_name string, source string) {
+	if this.sources == nil {
+		this.sources = make(map[string]string)
+	}
+	this.sources[block_name+"/"+para_name] = source
+}
+
+//----------------------------------------------------------------------------------------
+//! \fn []string ParameterInput.BlockNames(prefix string)
+//! \brief returns the sorted names of all blocks beginning with prefix
+
+func (this *ParameterInput) BlockNames(prefix string) []string {
+	this.rwlock.RLock()
+	defer this.rwlock.RUnlock()
+	var result []string
+	for _, block_name := range this.input_block.names {
+		if strings.HasPrefix(block_name, prefix) {
+			result = append(result, block_name)
+		}
 	}
-	this.input_block[block_name][para_name] = value
+	sort.Strings(result)
+	return result
 }
 
 //----------------------------------------------------------------------------------------
@@ -98,56 +560,162 @@ func (this *ParameterInput) SetParameter(block_name string, para_name string, va
 func (this *ParameterInput) ParameterDump() string {
 	this.rwlock.RLock()
 	defer this.rwlock.RUnlock()
-	if len(this.input_block) == 0 {return ""}
-	result, _ := json.MarshalIndent(this.input_block, "", "    ")
-	return string(result)
+	if len(this.input_block.names) == 0 {return ""}
+	return this.dumpJSON(func(block_name string, block *inputLine, para_name string) string {
+		return jsonValue(block, para_name)
+	})
+}
+
+// It's a private function. It writes the blocks in the same format as json.MarshalIndent
+// with 4 spaces, in insertion order, where value gives the JSON text of each parameter.
+// The caller must hold the lock.
+func (this *ParameterInput) dumpJSON(value func(block_name string, block *inputLine, para_name string) string) string {
+	var builder strings.Builder
+	builder.WriteString("{")
+	for i, block_name := range this.input_block.names {
+		if i > 0 {
+			builder.WriteString(",")
+		}
+		block, _ := this.input_block.get(block_name)
+		builder.WriteString("\n    " + jsonString(block_name) + ": {")
+		for j, para_name := range block.names {
+			if j > 0 {
+				builder.WriteString(",")
+			}
+			builder.WriteString("\n        " + jsonString(para_name) + ": " + value(block_name, block, para_name))
+		}
+		if len(block.names) > 0 {
+			builder.WriteString("\n    ")
+		}
+		builder.WriteString("}")
+	}
+	builder.WriteString("\n}")
+	return builder.String()
+}
+
+// It's a private function. It's the JSON text of a parameter, which is the text in the
+// input for a number or an array if it's valid JSON. An array is written on a single line.
+func jsonValue(block *inputLine, para_name string) string {
+	if literal, ok := block.literals[para_name]; ok && json.Valid([]byte(literal)) {
+		return literal
+	}
+	para, _ := block.get(para_name)
+	if elements, ok := arrayElements(para); ok {
+		texts := make([]string, len(elements))
+		for i, element := range elements {
+			texts[i] = jsonString(element)
+		}
+		return "[" + strings.Join(texts, ", ") + "]"
+	}
+	return jsonString(para)
+}
+
+// It's a private function. It's the JSON text of a value, without escaping HTML
+// characters unlike json.Marshal.
+func jsonString(value interface{}) string {
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(value)
+	return strings.TrimSuffix(buffer.String(), "\n")
 }
 
 //----------------------------------------------------------------------------------------
-//! \fn ParameterInput.ChangeNextTime(mesh_time float64)
-//! \brief rollback next_time by dt for each output block if mesh_time = -1, or add dt to
-//! next_time until next_time > mesh_time for each output block else
+//! \fn error ParameterInput.RollbackNextTime()
+//! \brief rollback next_time by dt for each output block
 //!
-//! If the user has added a new/fresh output round to multiple of dt, make sure that
-//! mesh_time - dt0 < next_time <= mesh_time, to ensure immediate writing.
-//! 
-//! It's assumed that you have checked whether some parameters exsits.
+//! It's called on the restart file before the input file is loaded, because the old dt is
+//! needed. Blocks without next_time have never been written, and are left to
+//! ForwardNextTime.
 
-func (this *ParameterInput) ChangeNextTime(mesh_time float64) {
+func (this *ParameterInput) RollbackNextTime() error {
 	this.rwlock.Lock()
 	defer this.rwlock.Unlock()
-	for block_name, block := range this.input_block {
-		// Slice of string means that everything should be in English.
-		if len(block_name) > 5 && block_name[:6] == "output" {
-			// dt must exist while next_time just need to exist if mesh_time = -1
-			dt, ok := block["dt"]
-			if !ok { panic("Get Parameter Error: \"dt\" isn't in block " + block_name) }
-			dt_value, ok := dt.(float64)
-			if !ok { panic("Parameter Type Error: \"dt\" in block " + block_name + " isn't an integer") }
-
-			next_time, ok := block["next_time"]
-			var next_time_value float64
-			if !ok {
-				if mesh_time == -1 {
-					panic("Get Parameter Error: \"next_time\" isn't in block " + block_name)
-				} else {
-					this.input_block[block_name]["next_time"] = math.Floor(mesh_time/dt_value) * dt_value
-					return
-				}
-			} else {
-				next_time_value, ok = next_time.(float64)
-				if !ok { panic("Parameter Type Error: \"next_time\" in block " + block_name + " isn't an integer") }
-			}
+	for _, block_name := range this.input_block.names {
+		if !strings.HasPrefix(block_name, "output") {
+			continue
+		}
+		block, _ := this.input_block.get(block_name)
+		next_time, ok := block.get("next_time")
+		if !ok {
+			continue
+		}
+		next_time_value, err := toReal(block_name, "next_time", next_time)
+		if err != nil {
+			return err
+		}
+		dt_value, err := this.getOutputDt(block_name)
+		if err != nil {
+			return err
+		}
+		this.setNextTime(block_name, next_time_value-dt_value)
+	}
+	return nil
+}
 
-			if mesh_time == -1 {
-				this.input_block[block_name]["next_time"] = next_time_value - dt_value
-			} else {
-				dt0 := dt_value * (math.Floor((mesh_time-next_time_value)/dt_value) + 1)
-				if dt0 > 0 {
-					this.input_block[block_name]["next_time"] = next_time_value + dt0
-				}
-			}
+//----------------------------------------------------------------------------------------
+//! \fn error ParameterInput.ForwardNextTime(mesh_time float64)
+//! \brief add multiple of dt to next_time until mesh_time - dt < next_time for each
+//! output block
+//!
+//! If the user has added a new/fresh output (without next_time), round it to multiple of
+//! dt, making sure that mesh_time - dt < next_time <= mesh_time to ensure immediate
+//! writing. After RollbackNextTime, next_time of the old outputs is in the same range.
+//! An output scheduled in the future (next_time > mesh_time) isn't changed, and blocks
+//! without dt (e.g. written every dcycle cycles) are skipped.
+
+func (this *ParameterInput) ForwardNextTime(mesh_time float64) error {
+	this.rwlock.Lock()
+	defer this.rwlock.Unlock()
+	for _, block_name := range this.input_block.names {
+		if !strings.HasPrefix(block_name, "output") {
+			continue
 		}
+		block, _ := this.input_block.get(block_name)
+		if _, ok := block.get("dt"); !ok {
+			continue
+		}
+		dt_value, err := this.getOutputDt(block_name)
+		if err != nil {
+			return err
+		}
+		next_time, ok := block.get("next_time")
+		if !ok {
+			this.setNextTime(block_name, math.Floor(mesh_time/dt_value)*dt_value)
+			continue
+		}
+		next_time_value, err := toReal(block_name, "next_time", next_time)
+		if err != nil {
+			return err
+		}
+		if next_time_value <= mesh_time-dt_value {
+			next_time_value += math.Floor((mesh_time-next_time_value)/dt_value) * dt_value
+			this.setNextTime(block_name, next_time_value)
+		}
+	}
+	return nil
+}
+
+// It's a private function. next_time is corrected by the code, so its source is "run time".
+// The caller must hold the lock.
+func (this *ParameterInput) setNextTime(block_name string, next_time float64) {
+	this.setValue(block_name, "next_time", next_time)
+	this.setSource(block_name, "next_time", SOURCE_RUNTIME)
+}
+
+// It's a private function. It returns the positive dt of an output block. The caller
+// must hold the lock.
+func (this *ParameterInput) getOutputDt(block_name string) (float64, error) {
+	dt, err := this.getValue(block_name, "dt")
+	if err != nil {
+		return 0, err
+	}
+	dt_value, err := toReal(block_name, "dt", dt)
+	if err != nil {
+		return 0, err
+	}
+	if dt_value <= 0 {
+		return 0, &ParameterValueError{block_name, "dt", dt, "must be positive"}
 	}
-	return
+	return dt_value, nil
 }
@@ -0,0 +1,110 @@
+package inputs
+
+import (
+	"fmt"
+	"strings"
+)
+
+//----------------------------------------------------------------------------------------
+// Runtime parameters can be changed during a run by reloading an overrides file, in any
+// input format. Only the parameters listed in RUNTIME_PARAMETERS may be changed, where a
+// block name ending with '*' matches any block with the prefix.
+
+var RUNTIME_PARAMETERS = []string{"time/tlim", "time/nlim", "output*/dt"}
+
+//----------------------------------------------------------------------------------------
+//! \struct ParameterChange
+//! \brief a parameter changed by ReloadFromFile
+
+type ParameterChange struct {
+	Block, Name       string
+	Old, New          interface{}
+	OldSource, Source string
+}
+
+func (this *ParameterChange) String() string {
+	return fmt.Sprintf("%s/%s changed from %s to %s (%s)", this.Block, this.Name,
+		formatValue(this.Old), formatValue(this.New), this.Source)
+}
+
+// It's a private function. It tells whether block/name is in RUNTIME_PARAMETERS.
+func isRuntimeParameter(block_name string, para_name string) bool {
+	for _, key := range RUNTIME_PARAMETERS {
+		slash := strings.IndexByte(key, '/')
+		if key[slash+1:] != para_name {
+			continue
+		}
+		pattern := key[:slash]
+		if pattern == block_name ||
+			strings.HasSuffix(pattern, "*") && strings.HasPrefix(block_name, pattern[:len(pattern)-1]) {
+			return true
+		}
+	}
+	return false
+}
+
+//----------------------------------------------------------------------------------------
+//! \fn ([]ParameterChange, error) ParameterInput.ReloadFromFile(filename string)
+//! \brief applies the parameters of an overrides file, returning the changed ones
+//!
+//! Every parameter in the file must be in RUNTIME_PARAMETERS, must already exist and its
+//! value must pass the schema of the block. Parameters whose values are unchanged are
+//! skipped. Nothing is changed if any error is found. The source of a changed value is
+//! "reload file:line".
+
+func (this *ParameterInput) ReloadFromFile(filename string) ([]ParameterChange, error) {
+	data, err := loadFile(filename, FORMAT_AUTO, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	this.rwlock.Lock()
+	defer this.rwlock.Unlock()
+	var changes []ParameterChange
+	for _, block_name := range data.blocks.names {
+		block, _ := data.blocks.get(block_name)
+		for _, para_name := range block.names {
+			para, _ := block.get(para_name)
+			if !isRuntimeParameter(block_name, para_name) {
+				return nil, &ParameterValueError{block_name, para_name, para,
+					"can't be changed during the run; only " + strings.Join(RUNTIME_PARAMETERS, ", ") + " can"}
+			}
+			old, err := this.getValue(block_name, para_name)
+			if err != nil {
+				return nil, err
+			}
+			if schema, ok := findSchema(block_name); ok {
+				para_schema := schema.Parameters[para_name]
+				if err := para_schema.check(block_name, para_name, para); err != nil {
+					return nil, err
+				}
+			}
+			if formatValue(old) == formatValue(para) {
+				continue
+			}
+			changes = append(changes, ParameterChange{block_name, para_name, old, para,
+				this.sources[block_name+"/"+para_name], "reload " + data.sources[block_name+"/"+para_name]})
+		}
+	}
+	for _, change := range changes {
+		this.setValue(change.Block, change.Name, change.New)
+		this.setSource(change.Block, change.Name, change.Source)
+	}
+	return changes, nil
+}
+
+//----------------------------------------------------------------------------------------
+//! \fn ParameterInput.RevertChanges(changes []ParameterChange)
+//! \brief restores the old values and sources of changes returned by ReloadFromFile
+
+func (this *ParameterInput) RevertChanges(changes []ParameterChange) {
+	this.rwlock.Lock()
+	defer this.rwlock.Unlock()
+	for i := len(changes) - 1; i >= 0; i-- {
+		change := &changes[i]
+		this.setValue(change.Block, change.Name, change.Old)
+		if change.OldSource != "" {
+			this.setSource(change.Block, change.Name, change.OldSource)
+		}
+	}
+}
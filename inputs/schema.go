@@ -0,0 +1,275 @@
+package inputs
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+//----------------------------------------------------------------------------------------
+// Schemas of input blocks. Physics modules declare the parameters they read by calling
+// RegisterSchema in their init functions, and ParameterInput.Validate checks the loaded
+// parameters against them. Blocks without a schema (e.g. <problem>) are not checked.
+
+type ParameterType int
+
+const (
+	TYPE_ANY ParameterType = iota
+	TYPE_INTEGER
+	TYPE_REAL
+	TYPE_BOOLEAN
+	TYPE_STRING
+	TYPE_INTEGER_ARRAY
+	TYPE_REAL_ARRAY
+	TYPE_BOOLEAN_ARRAY
+	TYPE_STRING_ARRAY
+)
+
+//----------------------------------------------------------------------------------------
+//! \struct ParameterSchema
+//! \brief allowed type, range and values of a parameter
+//!
+//! Min and Max are checked for numbers if they aren't nil, and Min itself isn't allowed
+//! if MinExclusive. Enum lists the allowed values (compared in the athinput format, so
+//! that it works for any type) if it isn't empty. For an array, they are checked for
+//! every element.
+
+type ParameterSchema struct {
+	Type         ParameterType
+	Min, Max     *float64
+	MinExclusive bool
+	Enum         []string
+}
+
+//----------------------------------------------------------------------------------------
+//! \struct BlockSchema
+//! \brief parameters allowed in a block. Unknown parameters are errors unless Open.
+
+type BlockSchema struct {
+	Parameters map[string]ParameterSchema
+	Open       bool
+}
+
+// A block name ending with '*' matches any block with the prefix, e.g. "output*".
+var schemas = make(map[string]BlockSchema)
+
+//----------------------------------------------------------------------------------------
+//! \fn RegisterSchema(block_name string, schema BlockSchema)
+//! \brief registers the schema of a block; it's not thread-safe, so call it in init()
+
+func RegisterSchema(block_name string, schema BlockSchema) {
+	schemas[block_name] = schema
+}
+
+//----------------------------------------------------------------------------------------
+//! \fn *float64 Bound(x float64)
+//! \brief helper to give Min and Max of ParameterSchema
+
+func Bound(x float64) *float64 {
+	return &x
+}
+
+// It's a private function. It finds the schema of a block.
+func findSchema(block_name string) (BlockSchema, bool) {
+	if schema, ok := schemas[block_name]; ok {
+		return schema, true
+	}
+	for name, schema := range schemas {
+		if strings.HasSuffix(name, "*") && strings.HasPrefix(block_name, name[:len(name)-1]) {
+			return schema, true
+		}
+	}
+	return BlockSchema{}, false
+}
+
+//----------------------------------------------------------------------------------------
+//! \struct UnknownParameterError
+//! \brief the parameter isn't allowed by the schema of the block. Suggestion is the most
+//! similar allowed name, or empty if there isn't any.
+
+type UnknownParameterError struct {
+	Block, Name, Suggestion string
+}
+
+func (this *UnknownParameterError) Error() string {
+	msg := fmt.Sprintf("Schema Error: Unknown parameter %s in block %s.", this.Name, this.Block)
+	if this.Suggestion != "" {
+		msg += fmt.Sprintf(" Did you mean %s?", this.Suggestion)
+	}
+	return msg
+}
+
+func (this *UnknownParameterError) Is(target error) bool { return target == ErrInput }
+
+//----------------------------------------------------------------------------------------
+//! \struct SchemaError
+//! \brief all errors found by ParameterInput.Validate
+
+type SchemaError struct {
+	Errors []error
+}
+
+func (this *SchemaError) Error() string {
+	msgs := make([]string, len(this.Errors))
+	for i, err := range this.Errors {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+func (this *SchemaError) Is(target error) bool { return target == ErrInput }
+
+//----------------------------------------------------------------------------------------
+//! \fn error ParameterInput.Validate()
+//! \brief checks all parameters against the registered schemas
+//!
+//! Every problem is reported in the returned *SchemaError, sorted by block and name.
+
+func (this *ParameterInput) Validate() error {
+	this.rwlock.RLock()
+	defer this.rwlock.RUnlock()
+	var errs []error
+	for _, block_name := range sortedNames(this.input_block.names) {
+		schema, ok := findSchema(block_name)
+		if !ok {
+			continue
+		}
+		block, _ := this.input_block.get(block_name)
+		for _, para_name := range sortedNames(block.names) {
+			para_schema, ok := schema.Parameters[para_name]
+			if !ok {
+				if !schema.Open {
+					errs = append(errs, &UnknownParameterError{block_name, para_name,
+						suggest(para_name, sortedKeys(schema.Parameters))})
+				}
+				continue
+			}
+			para, _ := block.get(para_name)
+			if err := para_schema.check(block_name, para_name, para); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return &SchemaError{errs}
+	}
+	return nil
+}
+
+// It's a private function. It checks a single value against the schema.
+func (this *ParameterSchema) check(block_name string, para_name string, para interface{}) error {
+	var err error
+	switch this.Type {
+	case TYPE_INTEGER:
+		_, err = toInteger(block_name, para_name, para)
+	case TYPE_REAL:
+		_, err = toReal(block_name, para_name, para)
+	case TYPE_BOOLEAN:
+		_, err = toBoolean(block_name, para_name, para)
+	case TYPE_STRING:
+		_, err = toString(block_name, para_name, para)
+	case TYPE_INTEGER_ARRAY:
+		_, err = toIntegerArray(block_name, para_name, para)
+	case TYPE_REAL_ARRAY:
+		_, err = toRealArray(block_name, para_name, para)
+	case TYPE_BOOLEAN_ARRAY:
+		_, err = toBooleanArray(block_name, para_name, para)
+	case TYPE_STRING_ARRAY:
+		_, err = toStringArray(block_name, para_name, para)
+	}
+	if err != nil {
+		return err
+	}
+
+	elements, ok := arrayElements(para)
+	if !ok {
+		elements = []interface{}{para}
+	}
+	for _, element := range elements {
+		if err := this.checkElement(block_name, para_name, para, element); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// It's a private function. It checks the range and the allowed values of a single value
+// or an element of an array para.
+func (this *ParameterSchema) checkElement(block_name string, para_name string, para interface{}, element interface{}) error {
+	if value, ok := element.(float64); ok {
+		if this.Min != nil && this.MinExclusive && value <= *this.Min {
+			return &ParameterValueError{block_name, para_name, para,
+				fmt.Sprintf("must be greater than %v", *this.Min)}
+		}
+		if this.Min != nil && value < *this.Min {
+			return &ParameterValueError{block_name, para_name, para,
+				fmt.Sprintf("must be at least %v", *this.Min)}
+		}
+		if this.Max != nil && value > *this.Max {
+			return &ParameterValueError{block_name, para_name, para,
+				fmt.Sprintf("must be at most %v", *this.Max)}
+		}
+	}
+	if len(this.Enum) > 0 {
+		value := formatValue(element)
+		for _, allowed := range this.Enum {
+			if value == allowed {
+				return nil
+			}
+		}
+		reason := "must be one of " + strings.Join(this.Enum, ", ")
+		if suggestion := suggest(value, this.Enum); suggestion != "" {
+			reason += "; did you mean " + suggestion + "?"
+		}
+		return &ParameterValueError{block_name, para_name, para, reason}
+	}
+	return nil
+}
+
+// It's a private function. It returns the most similar name, if it's similar enough.
+func suggest(name string, candidates []string) string {
+	best, best_dist := "", len(name)/3+2
+	for _, candidate := range candidates {
+		if dist := editDistance(name, candidate); dist < best_dist {
+			best, best_dist = candidate, dist
+		}
+	}
+	return best
+}
+
+// It's a private function. It's the Levenshtein distance between a and b.
+func editDistance(a string, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = prev[j-1] + cost
+			if prev[j]+1 < curr[j] {
+				curr[j] = prev[j] + 1
+			}
+			if curr[j-1]+1 < curr[j] {
+				curr[j] = curr[j-1] + 1
+			}
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+// It's a private function. It returns the sorted keys of a map with string keys.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
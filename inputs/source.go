@@ -0,0 +1,64 @@
+package inputs
+
+//----------------------------------------------------------------------------------------
+// Provenance of parameters. Every parameter records where its final value comes from:
+//   "file:line"                   input file (or "line N" when loaded from a string)
+//   "restart file:line"           restart file, by LoadFromRestartFile
+//   "env GOTHENA_block__name"     environment, by ModifyFromEnvironment
+//   "command line"                command line, by ModifyFromCmdline
+//   "default"                     default value inserted by GetOrAdd*
+//   "run time"                    set by the code with SetParameter, or next_time corrected
+//                                 by RollbackNextTime and ForwardNextTime
+//   "sweep"                       value of a member of a sweep, by ApplySweep
+//   "reload file:line"            overrides file applied during the run, by ReloadFromFile
+// A parameter whose source is unknown has an empty source.
+
+const (
+	SOURCE_CMDLINE = "command line"
+	SOURCE_DEFAULT = "default"
+	SOURCE_RUNTIME = "run time"
+)
+
+//----------------------------------------------------------------------------------------
+//! \fn (string, error) ParameterInput.Source(block string, name string)
+//! \brief returns the source of block/name; return error if it does not exist
+
+func (this *ParameterInput) Source(block_name string, para_name string) (string, error) {
+	this.rwlock.RLock()
+	defer this.rwlock.RUnlock()
+	if _, err := this.getValue(block_name, para_name); err != nil {
+		return "", err
+	}
+	return this.sources[block_name+"/"+para_name], nil
+}
+
+//----------------------------------------------------------------------------------------
+//! \fn string ParameterInput.ParameterDumpWithSources()
+//! \brief same as ParameterDump, but each value is annotated with its source
+//!
+//! Each parameter is dumped as {"value": value, "source": source}. It's meant to be read
+//! when debugging the inputs, and can't be loaded again.
+
+func (this *ParameterInput) ParameterDumpWithSources() string {
+	this.rwlock.RLock()
+	defer this.rwlock.RUnlock()
+	if len(this.input_block.names) == 0 {
+		return ""
+	}
+	return this.dumpJSON(func(block_name string, block *inputLine, para_name string) string {
+		return `{"value": ` + jsonValue(block, para_name) + `, "source": ` +
+			jsonString(this.sources[block_name+"/"+para_name]) + "}"
+	})
+}
+
+//----------------------------------------------------------------------------------------
+//! \fn string ParameterInput.AthinputDumpWithSources()
+//! \brief same as AthinputDump, but the source of each value is given as a comment
+//!
+//! The output can be loaded again by LoadFromAthinput, but the sources aren't.
+
+func (this *ParameterInput) AthinputDumpWithSources() string {
+	this.rwlock.RLock()
+	defer this.rwlock.RUnlock()
+	return this.dumpAthinput(true)
+}
@@ -0,0 +1,180 @@
+package inputs
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+//----------------------------------------------------------------------------------------
+// Parameter sweeps. A sweep varies some parameters of a base input, giving one member per
+// combination of values. The values of a parameter are either a list "block/name=a,b,c"
+// or an inclusive range of numbers "block/name=start:stop:step". The combinations are
+// either the Cartesian product of all lists (the first parameter varies slowest), or the
+// zip of lists of the same length.
+
+const (
+	SWEEP_PRODUCT = "product"
+	SWEEP_ZIP     = "zip"
+	SOURCE_SWEEP  = "sweep"
+	MAX_SWEEP     = 100000 // maximum number of values of a range, or of members
+)
+
+//----------------------------------------------------------------------------------------
+//! \struct SweepSpec
+//! \brief a parameter varied by a sweep, and its values
+
+type SweepSpec struct {
+	Block, Name string
+	Values      []interface{}
+}
+
+//----------------------------------------------------------------------------------------
+//! \fn (SweepSpec, error) ParseSweepSpec(spec string)
+//! \brief parses "block/name=a,b,c" or "block/name=start:stop:step"
+//!
+//! Values of a list are typed as in athinput files, and an array value "[x, y]" is kept
+//! as a single value.
+
+func ParseSweepSpec(spec string) (SweepSpec, error) {
+	slash := strings.IndexByte(spec, '/')
+	eq := strings.IndexByte(spec, '=')
+	if slash <= 0 || eq == -1 || eq < slash || len(strings.TrimSpace(spec[slash+1:eq])) == 0 {
+		return SweepSpec{}, &InputSyntaxError{File: SOURCE_SWEEP,
+			Msg: fmt.Sprintf("'%s' isn't in the form 'block/name=values'.", spec)}
+	}
+	result := SweepSpec{Block: strings.TrimSpace(spec[:slash]), Name: strings.TrimSpace(spec[slash+1 : eq])}
+	values := strings.TrimSpace(spec[eq+1:])
+	var err error
+	if parts := strings.Split(values, ":"); len(parts) == 3 && !strings.HasPrefix(values, "[") {
+		result.Values, err = sweepRange(parts)
+	} else {
+		result.Values, err = sweepList(values)
+	}
+	if err != nil {
+		return SweepSpec{}, &InputSyntaxError{File: SOURCE_SWEEP, Msg: fmt.Sprintf("%s in '%s'.", err, spec)}
+	}
+	return result, nil
+}
+
+// It's a private function. It splits a list by the commas outside arrays.
+func sweepList(str string) ([]interface{}, error) {
+	var result []interface{}
+	depth, start := 0, 0
+	for i := 0; i <= len(str); i++ {
+		if i < len(str) && str[i] == '[' {
+			depth++
+		} else if i < len(str) && str[i] == ']' {
+			depth--
+		} else if i == len(str) || str[i] == ',' && depth == 0 {
+			value := strings.TrimSpace(str[start:i])
+			if len(value) == 0 {
+				return nil, fmt.Errorf("Empty value")
+			}
+			result = append(result, parseValue(value))
+			start = i + 1
+		}
+	}
+	return result, nil
+}
+
+// It's a private function. It expands "start:stop:step", including stop if it's reached
+// up to rounding errors. Values are rounded to 12 significant digits, so that 0.1 steps
+// give 0.3 instead of 0.30000000000000004.
+func sweepRange(parts []string) ([]interface{}, error) {
+	var bounds [3]float64
+	for i, part := range parts {
+		value, ok := parseValue(strings.TrimSpace(part)).(float64)
+		if !ok {
+			return nil, fmt.Errorf("'%s' isn't a number", part)
+		}
+		bounds[i] = value
+	}
+	start, stop, step := bounds[0], bounds[1], bounds[2]
+	if step == 0 || (stop-start)/step < 0 {
+		return nil, fmt.Errorf("The step doesn't go from start to stop")
+	}
+	count := math.Floor((stop-start)/step+1e-9) + 1
+	if count > MAX_SWEEP {
+		return nil, fmt.Errorf("More than %d values", MAX_SWEEP)
+	}
+	result := make([]interface{}, int(count))
+	for i := range result {
+		result[i], _ = strconv.ParseFloat(strconv.FormatFloat(start+float64(i)*step, 'g', 12, 64), 64)
+	}
+	return result, nil
+}
+
+//----------------------------------------------------------------------------------------
+//! \fn ([][]interface{}, error) SweepMembers(specs []SweepSpec, mode string)
+//! \brief returns the values of each member, in the order of specs
+//!
+//! mode is SWEEP_PRODUCT or SWEEP_ZIP.
+
+func SweepMembers(specs []SweepSpec, mode string) ([][]interface{}, error) {
+	if len(specs) == 0 {
+		return nil, &InputSyntaxError{File: SOURCE_SWEEP, Msg: "No parameter to sweep."}
+	}
+	switch mode {
+	case SWEEP_ZIP:
+		var members [][]interface{}
+		for i := range specs[0].Values {
+			member := make([]interface{}, len(specs))
+			for j, spec := range specs {
+				if len(spec.Values) != len(specs[0].Values) {
+					return nil, &InputSyntaxError{File: SOURCE_SWEEP,
+						Msg: fmt.Sprintf("%s/%s has %d values while %s/%s has %d, but zip needs the same number.",
+							spec.Block, spec.Name, len(spec.Values), specs[0].Block, specs[0].Name, len(specs[0].Values))}
+				}
+				member[j] = spec.Values[i]
+			}
+			members = append(members, member)
+		}
+		return members, nil
+	case SWEEP_PRODUCT:
+		members := [][]interface{}{{}}
+		for _, spec := range specs {
+			if len(members)*len(spec.Values) > MAX_SWEEP {
+				return nil, &InputSyntaxError{File: SOURCE_SWEEP, Msg: fmt.Sprintf("More than %d members.", MAX_SWEEP)}
+			}
+			var next [][]interface{}
+			for _, member := range members {
+				for _, value := range spec.Values {
+					next = append(next, append(append([]interface{}{}, member...), value))
+				}
+			}
+			members = next
+		}
+		return members, nil
+	}
+	return nil, &InputSyntaxError{File: SOURCE_SWEEP,
+		Msg: fmt.Sprintf("Unknown sweep mode '%s', expected %s or %s.", mode, SWEEP_PRODUCT, SWEEP_ZIP)}
+}
+
+//----------------------------------------------------------------------------------------
+//! \fn *ParameterInput ParameterInput.Clone()
+//! \brief returns a copy of all parameters and their sources, by merging them into an
+//! empty ParameterInput. The usage of parameters isn't copied.
+
+func (this *ParameterInput) Clone() *ParameterInput {
+	this.rwlock.RLock()
+	defer this.rwlock.RUnlock()
+	result := &ParameterInput{}
+	result.mergeBlocks(&inputData{&this.input_block, this.sources})
+	return result
+}
+
+//----------------------------------------------------------------------------------------
+//! \fn error ParameterInput.ApplySweep(specs []SweepSpec, values []interface{})
+//! \brief sets the parameters of specs to the values of a member
+//!
+//! The rules are the same as ModifyFromCmdline, and the source of the values is "sweep".
+
+func (this *ParameterInput) ApplySweep(specs []SweepSpec, values []interface{}) error {
+	mods := make([]modification, len(specs))
+	for i, spec := range specs {
+		mods[i] = modification{spec.Block, spec.Name, values[i], SOURCE_SWEEP}
+	}
+	return this.modify(mods)
+}
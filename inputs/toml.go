@@ -0,0 +1,288 @@
+package inputs
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+//----------------------------------------------------------------------------------------
+// TOML input. Each table is a block and each key/value pair is a parameter, e.g.
+//   [time]
+//   tlim = 1.0 # comment
+// Values are basic or literal strings, integers, floats, booleans and arrays of them,
+// written on a single line. Nested tables, arrays of tables, inline tables, dates and
+// multi-line strings aren't supported. Files to include are given by "@include" =
+// ["file", ...] before the first table.
+
+//----------------------------------------------------------------------------------------
+//! \fn error ParameterInput.LoadFromTOML(str []byte)
+//! \brief Load input parameters from a string in TOML format
+//!
+//! Like LoadFromByte, nothing is changed if any error is found.
+
+func (this *ParameterInput) LoadFromTOML(str []byte) error {
+	return this.loadBytes(parseTOML, str)
+}
+
+// It's a private function. It parses a TOML input, returning the blocks, the line number
+// of each parameter keyed by "block/name" and the files to include.
+func parseTOML(str []byte) (*inputBlocks, map[string]int, []string, error) {
+	temp := &inputBlocks{}
+	lines := make(map[string]int)
+	var includes []string
+	var block *inputLine
+	var block_name string
+	for i, line := range strings.Split(string(str), "\n") {
+		line_num := i + 1
+		line = strings.TrimSpace(line)
+		// Skip blank lines and comment lines.
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+
+		if line[0] == '[' {
+			if strings.HasPrefix(line, "[[") {
+				return nil, nil, nil, &InputSyntaxError{Line: line_num, Msg: "Arrays of tables aren't supported."}
+			}
+			name, rest, err := tomlKey(line[1:])
+			if err != nil {
+				return nil, nil, nil, &InputSyntaxError{Line: line_num, Msg: err.Error()}
+			}
+			rest = strings.TrimSpace(rest)
+			if strings.HasPrefix(rest, ".") {
+				return nil, nil, nil, &InputSyntaxError{Line: line_num, Msg: "Nested tables aren't supported."}
+			}
+			if !strings.HasPrefix(rest, "]") || !tomlEnd(rest[1:]) {
+				return nil, nil, nil, &InputSyntaxError{Line: line_num, Msg: fmt.Sprintf("Table name '%s' isn't properly ended.", line)}
+			}
+			if _, ok := temp.get(name); ok {
+				return nil, nil, nil, &InputSyntaxError{Line: line_num, Msg: fmt.Sprintf("Table [%s] is defined twice.", name)}
+			}
+			block_name, block = name, temp.add(name)
+			continue
+		}
+
+		para_name, rest, err := tomlKey(line)
+		if err != nil {
+			return nil, nil, nil, &InputSyntaxError{Line: line_num, Msg: err.Error()}
+		}
+		rest = strings.TrimSpace(rest)
+		if strings.HasPrefix(rest, ".") {
+			return nil, nil, nil, &InputSyntaxError{Line: line_num, Msg: "Dotted keys aren't supported."}
+		}
+		if !strings.HasPrefix(rest, "=") {
+			return nil, nil, nil, &InputSyntaxError{Line: line_num, Msg: fmt.Sprintf("'%s' isn't in the form 'key = value'.", line)}
+		}
+		text := strings.TrimSpace(rest[1:])
+		para, rest, err := tomlValue(text)
+		if err == nil && !tomlEnd(rest) {
+			err = fmt.Errorf("Unexpected '%s' after the value.", strings.TrimSpace(rest))
+		}
+		if err != nil {
+			return nil, nil, nil, &InputSyntaxError{Line: line_num, Msg: err.Error()}
+		}
+
+		if block == nil {
+			if para_name != INCLUDE_KEY {
+				return nil, nil, nil, &InputSyntaxError{Line: line_num, Msg: fmt.Sprintf("Key '%s' isn't in any table.", para_name)}
+			}
+			if includes, err = fileNames(para); err != nil {
+				return nil, nil, nil, &InputSyntaxError{Line: line_num, Msg: err.Error()}
+			}
+			continue
+		}
+		if _, ok := block.get(para_name); ok {
+			return nil, nil, nil, &InputSyntaxError{Line: line_num,
+				Msg: fmt.Sprintf("Key %s is defined twice in table [%s].", para_name, block_name)}
+		}
+		if para, err = normalizeParameter(block_name, para_name, para); err != nil {
+			return nil, nil, nil, err
+		}
+		lines[block_name+"/"+para_name] = line_num
+		block.setLiteral(para_name, para, strings.TrimSpace(text[:len(text)-len(rest)]))
+	}
+	return temp, lines, includes, nil
+}
+
+// It's a private function. It converts the value of INCLUDE_KEY to the file names.
+func fileNames(para interface{}) ([]string, error) {
+	array, ok := para.([]interface{})
+	if !ok {
+		return nil, errors.New(INCLUDE_KEY + " must be an array of file names.")
+	}
+	result := make([]string, len(array))
+	for i, element := range array {
+		if result[i], ok = element.(string); !ok {
+			return nil, errors.New(INCLUDE_KEY + " must be an array of file names.")
+		}
+	}
+	return result, nil
+}
+
+// It's a private function. Only a comment may follow a value or a table name.
+func tomlEnd(rest string) bool {
+	rest = strings.TrimSpace(rest)
+	return len(rest) == 0 || rest[0] == '#'
+}
+
+// It's a private function. It parses a bare or quoted key at the beginning of str,
+// returning the key and the rest of str.
+func tomlKey(str string) (string, string, error) {
+	str = strings.TrimLeft(str, " \t")
+	if strings.HasPrefix(str, "\"") || strings.HasPrefix(str, "'") {
+		key, rest, err := tomlString(str)
+		return key, rest, err
+	}
+	end := 0
+	for end < len(str) && (isIdentChar(str[end]) && str[end] != '.' || str[end] == '-') {
+		end++
+	}
+	if end == 0 {
+		return "", "", errors.New("Empty key.")
+	}
+	return str[:end], str[end:], nil
+}
+
+// It's a private function. It parses the value at the beginning of str, returning the
+// value and the rest of str.
+func tomlValue(str string) (interface{}, string, error) {
+	if len(str) == 0 {
+		return nil, "", errors.New("Missing value.")
+	}
+	switch str[0] {
+	case '"', '\'':
+		return tomlString(str)
+	case '{':
+		return nil, "", errors.New("Inline tables aren't supported.")
+	case '[':
+		var array []interface{}
+		rest := strings.TrimLeft(str[1:], " \t")
+		for !strings.HasPrefix(rest, "]") {
+			element, after, err := tomlValue(rest)
+			if err != nil {
+				return nil, "", err
+			}
+			array = append(array, element)
+			rest = strings.TrimLeft(after, " \t")
+			if strings.HasPrefix(rest, ",") {
+				rest = strings.TrimLeft(rest[1:], " \t")
+			} else if !strings.HasPrefix(rest, "]") {
+				return nil, "", errors.New("The array isn't properly ended on the same line.")
+			}
+		}
+		return array, rest[1:], nil
+	}
+
+	end := strings.IndexAny(str, " \t,]#")
+	if end == -1 {
+		end = len(str)
+	}
+	token := str[:end]
+	switch token {
+	case "true":
+		return true, str[end:], nil
+	case "false":
+		return false, str[end:], nil
+	}
+	number, err := tomlNumber(token)
+	return number, str[end:], err
+}
+
+// It's a private function. It parses an integer or a float.
+func tomlNumber(token string) (float64, error) {
+	invalid := fmt.Errorf("Invalid value '%s'.", token)
+	if strings.HasPrefix(token, "0x") || strings.HasPrefix(token, "0o") || strings.HasPrefix(token, "0b") {
+		value, err := strconv.ParseInt(token, 0, 64)
+		if err != nil {
+			return 0, invalid
+		}
+		return float64(value), nil
+	}
+	switch strings.TrimLeft(token, "+-") {
+	case "inf", "nan":
+		return 0, errors.New("Non-finite numbers aren't allowed.")
+	}
+	// An underscore must be between two digits.
+	for i := 0; i < len(token); i++ {
+		c := token[i]
+		if c == '_' && (i == 0 || i == len(token)-1 || !isDigit(token[i-1]) || !isDigit(token[i+1])) {
+			return 0, invalid
+		}
+		if !isDigit(c) && !strings.ContainsRune("+-._eE", rune(c)) {
+			return 0, invalid
+		}
+	}
+	value, err := strconv.ParseFloat(strings.ReplaceAll(token, "_", ""), 64)
+	if err != nil || math.IsInf(value, 0) {
+		return 0, invalid
+	}
+	return value, nil
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// It's a private function. It parses a basic ("...") or literal ('...') string at the
+// beginning of str, returning the string and the rest of str.
+func tomlString(str string) (string, string, error) {
+	if strings.HasPrefix(str, `"""`) || strings.HasPrefix(str, "'''") {
+		return "", "", errors.New("Multi-line strings aren't supported.")
+	}
+	if str[0] == '\'' {
+		end := strings.IndexByte(str[1:], '\'')
+		if end == -1 {
+			return "", "", errors.New("The string isn't properly ended.")
+		}
+		return str[1 : end+1], str[end+2:], nil
+	}
+	var builder strings.Builder
+	for i := 1; i < len(str); i++ {
+		switch str[i] {
+		case '"':
+			return builder.String(), str[i+1:], nil
+		case '\\':
+			if i+1 == len(str) {
+				return "", "", errors.New("The string isn't properly ended.")
+			}
+			i++
+			switch str[i] {
+			case 'b':
+				builder.WriteByte('\b')
+			case 't':
+				builder.WriteByte('\t')
+			case 'n':
+				builder.WriteByte('\n')
+			case 'f':
+				builder.WriteByte('\f')
+			case 'r':
+				builder.WriteByte('\r')
+			case '"', '\\':
+				builder.WriteByte(str[i])
+			case 'u', 'U':
+				size := 4
+				if str[i] == 'U' {
+					size = 8
+				}
+				if i+size >= len(str) {
+					return "", "", errors.New("Invalid unicode escape.")
+				}
+				code, err := strconv.ParseUint(str[i+1:i+1+size], 16, 32)
+				if err != nil || !utf8.ValidRune(rune(code)) {
+					return "", "", errors.New("Invalid unicode escape.")
+				}
+				builder.WriteRune(rune(code))
+				i += size
+			default:
+				return "", "", fmt.Errorf("Invalid escape '\\%c'.", str[i])
+			}
+		default:
+			builder.WriteByte(str[i])
+		}
+	}
+	return "", "", errors.New("The string isn't properly ended.")
+}
@@ -0,0 +1,155 @@
+package inputs
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// It checks the values of pin, keyed by "block/name".
+func checkParameters(t *testing.T, pin *ParameterInput, want map[string]interface{}) {
+	t.Helper()
+	for key, value := range want {
+		slash := strings.IndexByte(key, '/')
+		got, err := pin.GetParameter(key[:slash], key[slash+1:])
+		if err != nil || !reflect.DeepEqual(got, value) {
+			t.Errorf("%s = %#v, %v, want %#v", key, got, err, value)
+		}
+	}
+}
+
+// It checks that err is an InputSyntaxError at line containing msg.
+func checkSyntaxError(t *testing.T, input string, err error, line int, msg string) {
+	t.Helper()
+	var syntax_err *InputSyntaxError
+	if !errors.As(err, &syntax_err) || syntax_err.Line != line || !strings.Contains(err.Error(), msg) {
+		t.Errorf("%q: got %v, want a syntax error at line %d with %q", input, err, line, msg)
+	}
+}
+
+func TestParseTOML(t *testing.T) {
+	input := `# comment
+[time]
+tlim = 1.0  # comment
+nlim = -1
+"quoted key" = 'literal \n'
+[mesh]
+nx1 = 1_000
+x1min = -0.5e1
+hex = 0x1F
+octal = 0o17
+binary = 0b101
+periodic = true
+ix1_bc = "outflow # not a comment"
+escapes = "tab\t\"q\" \u00e9"
+[output1]
+variable = ["rho", 'press']
+empty = []
+numbers = [1, 2.5, -3]
+flags = [true, false,]
+`
+	var pin ParameterInput
+	if err := pin.LoadFromTOML([]byte(input)); err != nil {
+		t.Fatal(err)
+	}
+	checkParameters(t, &pin, map[string]interface{}{
+		"time/tlim":        1.0,
+		"time/nlim":        -1.0,
+		"time/quoted key":  `literal \n`,
+		"mesh/nx1":         1000.0,
+		"mesh/x1min":       -5.0,
+		"mesh/hex":         31.0,
+		"mesh/octal":       15.0,
+		"mesh/binary":      5.0,
+		"mesh/periodic":    true,
+		"mesh/ix1_bc":      "outflow # not a comment",
+		"mesh/escapes":     "tab\t\"q\" \u00e9",
+		"output1/variable": []string{"rho", "press"},
+		"output1/empty":    []interface{}{},
+		"output1/numbers":  []float64{1, 2.5, -3},
+		"output1/flags":    []bool{true, false},
+	})
+	if names := pin.BlockNames(""); !reflect.DeepEqual(names, []string{"mesh", "output1", "time"}) {
+		t.Errorf("BlockNames = %v", names)
+	}
+	if source, _ := pin.Source("mesh", "hex"); source != "line 9" {
+		t.Errorf("source of mesh/hex = %q, want line 9", source)
+	}
+}
+
+func TestParseTOMLErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		line  int
+		msg   string
+	}{
+		{"tlim = 1", 1, "isn't in any table"},
+		{"[time]\ntlim", 2, "isn't in the form 'key = value'"},
+		{"[time]\ntlim =", 2, "Missing value"},
+		{"[time]\ntlim = 1\ntlim = 2", 3, "defined twice"},
+		{"[time]\n[time]", 2, "defined twice"},
+		{"[time\n", 1, "isn't properly ended"},
+		{"[[time]]", 1, "Arrays of tables"},
+		{"[time.sub]", 1, "Nested tables"},
+		{"[time]\na.b = 1", 2, "Dotted keys"},
+		{"[time]\na = {b = 1}", 2, "Inline tables"},
+		{"[time]\na = \"\"\"x\"\"\"", 2, "Multi-line strings"},
+		{"[time]\na = \"x", 2, "isn't properly ended"},
+		{"[time]\na = 'x", 2, "isn't properly ended"},
+		{"[time]\na = \"\\q\"", 2, "Invalid escape"},
+		{"[time]\na = \"\\u12\"", 2, "Invalid unicode escape"},
+		{"[time]\na = [1, 2", 2, "isn't properly ended"},
+		{"[time]\na = 1 2", 2, "Unexpected '2'"},
+		{"[time]\na = inf", 2, "Non-finite"},
+		{"[time]\na = 1__0", 2, "Invalid value"},
+		{"[time]\na = _1", 2, "Invalid value"},
+		{"[time]\na = yes", 2, "Invalid value"},
+		{"[time]\na = 1979-05-27", 2, "Invalid value"},
+		{"\"@include\" = [1]\n", 1, "must be an array of file names"},
+	}
+	for _, test := range tests {
+		var pin ParameterInput
+		err := pin.LoadFromTOML([]byte(test.input))
+		checkSyntaxError(t, test.input, err, test.line, test.msg)
+		if len(pin.BlockNames("")) != 0 {
+			t.Errorf("%q: parameters are loaded despite the error", test.input)
+		}
+	}
+	var pin ParameterInput
+	err := pin.LoadFromTOML([]byte("[mesh]\nnx1 = [1, \"a\"]\n"))
+	if err == nil || !errors.Is(err, ErrInput) {
+		t.Errorf("mixed array: got %v, want an input error", err)
+	}
+}
+
+func TestTOMLFiles(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, text string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	write("base.yaml", "time:\n  tlim: 1.0\n  nlim: 10\n")
+	deck := write("deck.toml", "\"@include\" = [\"base.yaml\"]\n[time]\nnlim = 20\n")
+	var pin ParameterInput
+	if err := pin.LoadFromFile(deck); err != nil {
+		t.Fatal(err)
+	}
+	checkParameters(t, &pin, map[string]interface{}{"time/tlim": 1.0, "time/nlim": 20.0})
+	if source, _ := pin.Source("time", "nlim"); source != deck+":3" {
+		t.Errorf("source of time/nlim = %q, want %s:3", source, deck)
+	}
+
+	// The format given to LoadFromFileAs wins over the extension.
+	athinput := write("deck.txt", "[time]\ntlim = 2.0\n")
+	pin = ParameterInput{}
+	if err := pin.LoadFromFileAs(athinput, FORMAT_TOML); err != nil {
+		t.Fatal(err)
+	}
+	checkParameters(t, &pin, map[string]interface{}{"time/tlim": 2.0})
+}
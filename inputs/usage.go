@@ -0,0 +1,94 @@
+package inputs
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// It's a private function. It records that block/name is read by the code.
+func (this *ParameterInput) markAccessed(block_name string, para_name string) {
+	this.usage_lock.Lock()
+	defer this.usage_lock.Unlock()
+	if this.accessed == nil {
+		this.accessed = make(map[string]bool)
+	}
+	this.accessed[block_name+"/"+para_name] = true
+}
+
+// It's a private function. It records that block/name is inserted with the default value
+// def, which is reported even if the parameter is changed later.
+func (this *ParameterInput) markDefaulted(block_name string, para_name string, def interface{}) {
+	this.usage_lock.Lock()
+	defer this.usage_lock.Unlock()
+	if this.defaulted == nil {
+		this.defaulted = make(map[string]interface{})
+	}
+	this.defaulted[block_name+"/"+para_name] = def
+}
+
+//----------------------------------------------------------------------------------------
+//! \fn []string ParameterInput.UnusedParameters()
+//! \brief returns the sorted "block/name" of all parameters never read by a getter
+//!
+//! The <comment> block is only informational, and parameters set by the code itself
+//! (SetParameter, e.g. restart/time) aren't from the input, so they're never reported.
+
+func (this *ParameterInput) UnusedParameters() []string {
+	this.rwlock.RLock()
+	defer this.rwlock.RUnlock()
+	this.usage_lock.Lock()
+	defer this.usage_lock.Unlock()
+	var result []string
+	for _, block_name := range sortedNames(this.input_block.names) {
+		if block_name == "comment" {
+			continue
+		}
+		block, _ := this.input_block.get(block_name)
+		for _, para_name := range sortedNames(block.names) {
+			key := block_name + "/" + para_name
+			if !this.accessed[key] && this.sources[key] != SOURCE_RUNTIME {
+				result = append(result, key)
+			}
+		}
+	}
+	return result
+}
+
+//----------------------------------------------------------------------------------------
+//! \fn []string ParameterInput.DefaultedParameters()
+//! \brief returns the sorted "block/name" of all parameters inserted with default values
+
+func (this *ParameterInput) DefaultedParameters() []string {
+	this.usage_lock.Lock()
+	defer this.usage_lock.Unlock()
+	result := make([]string, 0, len(this.defaulted))
+	for key := range this.defaulted {
+		result = append(result, key)
+	}
+	sort.Strings(result)
+	return result
+}
+
+//----------------------------------------------------------------------------------------
+//! \fn string ParameterInput.UsageReport()
+//! \brief summary of unused and defaulted parameters
+//!
+//! Every line starts with '#', so the report can be appended to an athinput dump.
+
+func (this *ParameterInput) UsageReport() string {
+	var builder strings.Builder
+	unused := this.UnusedParameters()
+	builder.WriteString(fmt.Sprintf("# %d unused parameter(s), never read during the run:\n", len(unused)))
+	for _, key := range unused {
+		builder.WriteString("#   " + key + "\n")
+	}
+	defaulted := this.DefaultedParameters()
+	builder.WriteString(fmt.Sprintf("# %d parameter(s) set to default values:\n", len(defaulted)))
+	this.usage_lock.Lock()
+	defer this.usage_lock.Unlock()
+	for _, key := range defaulted {
+		builder.WriteString(fmt.Sprintf("#   %s = %s\n", key, formatValue(this.defaulted[key])))
+	}
+	return builder.String()
+}
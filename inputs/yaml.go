@@ -0,0 +1,311 @@
+package inputs
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+//----------------------------------------------------------------------------------------
+// YAML input. Each top-level key is a block, and the indented key/value pairs below it
+// are its parameters, e.g.
+//   time:
+//     tlim: 1.0 # comment
+// Values are plain or quoted scalars, typed by the YAML 1.2 core schema: booleans,
+// integers and floats are numbers, null isn't allowed and anything else is a string.
+// Arrays are flow sequences of scalars, e.g. [rho, press]. Deeper nesting, block
+// sequences, block scalars, anchors, tags and multiple documents aren't supported. Files
+// to include are given by a top-level "@include": [file, ...], either as a flow or a
+// block sequence.
+
+//----------------------------------------------------------------------------------------
+//! \fn error ParameterInput.LoadFromYAML(str []byte)
+//! \brief Load input parameters from a string in YAML format
+//!
+//! Like LoadFromByte, nothing is changed if any error is found.
+
+func (this *ParameterInput) LoadFromYAML(str []byte) error {
+	return this.loadBytes(parseYAML, str)
+}
+
+// It's a private function. It parses a YAML input, returning the blocks, the line number
+// of each parameter keyed by "block/name" and the files to include.
+func parseYAML(str []byte) (*inputBlocks, map[string]int, []string, error) {
+	temp := &inputBlocks{}
+	lines := make(map[string]int)
+	var includes []string
+	var block *inputLine
+	var block_name string
+	indent := 0 // indentation of the parameters in the block, 0 before the first one
+	in_include := false
+	started := false
+	for i, line := range strings.Split(string(str), "\n") {
+		line_num := i + 1
+		line = strings.TrimRight(yamlStripComment(line), " \t\r")
+		if len(strings.TrimSpace(line)) == 0 {
+			continue
+		}
+		if line == "---" {
+			if started {
+				return nil, nil, nil, &InputSyntaxError{Line: line_num, Msg: "Multiple documents aren't supported."}
+			}
+			started = true
+			continue
+		}
+		if line == "..." {
+			break
+		}
+		started = true
+		content := strings.TrimLeft(line, " ")
+		level := len(line) - len(content)
+		if strings.HasPrefix(content, "\t") {
+			return nil, nil, nil, &InputSyntaxError{Line: line_num, Msg: "Tabs aren't allowed for indentation."}
+		}
+
+		if level == 0 {
+			key, value, err := yamlKeyValue(content)
+			if err != nil {
+				return nil, nil, nil, &InputSyntaxError{Line: line_num, Msg: err.Error()}
+			}
+			block, in_include = nil, false
+			if key == INCLUDE_KEY {
+				if value == "" {
+					in_include = true
+					continue
+				}
+				para, err := yamlScalar(value)
+				if err == nil {
+					includes, err = fileNames(para)
+				}
+				if err != nil {
+					return nil, nil, nil, &InputSyntaxError{Line: line_num, Msg: err.Error()}
+				}
+				continue
+			}
+			if value != "" {
+				return nil, nil, nil, &InputSyntaxError{Line: line_num, Msg: fmt.Sprintf("Parameter '%s' isn't in any block.", content)}
+			}
+			if _, ok := temp.get(key); ok {
+				return nil, nil, nil, &InputSyntaxError{Line: line_num, Msg: fmt.Sprintf("Block %s is defined twice.", key)}
+			}
+			block_name, block, indent = key, temp.add(key), 0
+			continue
+		}
+
+		if in_include {
+			if content != "-" && !strings.HasPrefix(content, "- ") {
+				return nil, nil, nil, &InputSyntaxError{Line: line_num, Msg: INCLUDE_KEY + " must be an array of file names."}
+			}
+			para, err := yamlScalar(strings.TrimSpace(content[1:]))
+			file, ok := para.(string)
+			if err != nil || !ok {
+				return nil, nil, nil, &InputSyntaxError{Line: line_num, Msg: INCLUDE_KEY + " must be an array of file names."}
+			}
+			includes = append(includes, file)
+			continue
+		}
+		if block == nil {
+			return nil, nil, nil, &InputSyntaxError{Line: line_num, Msg: "Unexpected indentation."}
+		}
+		if indent == 0 {
+			indent = level
+		} else if level > indent {
+			return nil, nil, nil, &InputSyntaxError{Line: line_num, Msg: "Nested mappings aren't supported."}
+		} else if level < indent {
+			return nil, nil, nil, &InputSyntaxError{Line: line_num, Msg: "Inconsistent indentation."}
+		}
+		if content == "-" || strings.HasPrefix(content, "- ") {
+			return nil, nil, nil, &InputSyntaxError{Line: line_num, Msg: "Sequences aren't supported."}
+		}
+		para_name, value, err := yamlKeyValue(content)
+		if err != nil {
+			return nil, nil, nil, &InputSyntaxError{Line: line_num, Msg: err.Error()}
+		}
+		if value == "" {
+			return nil, nil, nil, &InputSyntaxError{Line: line_num,
+				Msg: fmt.Sprintf("Parameter %s has no value. Nested mappings aren't supported.", para_name)}
+		}
+		para, err := yamlScalar(value)
+		if err != nil {
+			return nil, nil, nil, &InputSyntaxError{Line: line_num, Msg: err.Error()}
+		}
+		if _, ok := block.get(para_name); ok {
+			return nil, nil, nil, &InputSyntaxError{Line: line_num,
+				Msg: fmt.Sprintf("Parameter %s is defined twice in block %s.", para_name, block_name)}
+		}
+		if para, err = normalizeParameter(block_name, para_name, para); err != nil {
+			return nil, nil, nil, err
+		}
+		lines[block_name+"/"+para_name] = line_num
+		block.setLiteral(para_name, para, value)
+	}
+	return temp, lines, includes, nil
+}
+
+// It's a private function. It removes the comment of a line. A '#' starts a comment at
+// the beginning of the line or after a blank, outside quoted scalars.
+func yamlStripComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			// A quote only starts a quoted scalar at the beginning of a key or a value.
+			before := strings.TrimRight(line[:i], " \t")
+			if before == "" || strings.ContainsRune(":,[-", rune(before[len(before)-1])) {
+				quote = c
+			}
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// It's a private function. It splits "key: value", where the value may be empty.
+func yamlKeyValue(content string) (string, string, error) {
+	key, rest := "", content
+	if content[0] == '"' || content[0] == '\'' {
+		end := yamlQuoteEnd(content)
+		if end == -1 {
+			return "", "", errors.New("The quoted key isn't properly ended.")
+		}
+		para, err := yamlScalar(content[:end+1])
+		if err != nil {
+			return "", "", err
+		}
+		key, rest = para.(string), content[end+1:]
+	} else {
+		colon := strings.Index(content+" ", ": ")
+		if colon == -1 {
+			return "", "", fmt.Errorf("'%s' isn't in the form 'key: value'.", content)
+		}
+		key, rest = strings.TrimSpace(content[:colon]), content[colon:]
+	}
+	if !strings.HasPrefix(rest, ":") || len(rest) > 1 && rest[1] != ' ' {
+		return "", "", fmt.Errorf("'%s' isn't in the form 'key: value'.", content)
+	}
+	if len(key) == 0 {
+		return "", "", errors.New("Empty key.")
+	}
+	return key, strings.TrimSpace(rest[1:]), nil
+}
+
+// It's a private function. It returns the index of the quote ending the quoted scalar
+// at the beginning of str, or -1.
+func yamlQuoteEnd(str string) int {
+	quote := str[0]
+	for i := 1; i < len(str); i++ {
+		if quote == '"' && str[i] == '\\' {
+			i++
+		} else if str[i] == quote {
+			if quote == '\'' && i+1 < len(str) && str[i+1] == '\'' {
+				i++ // '' is an escaped single quote
+				continue
+			}
+			return i
+		}
+	}
+	return -1
+}
+
+var (
+	yamlInteger = regexp.MustCompile(`^[-+]?[0-9]+$`)
+	yamlOctal   = regexp.MustCompile(`^0o[0-7]+$`)
+	yamlHex     = regexp.MustCompile(`^0x[0-9a-fA-F]+$`)
+	yamlFloat   = regexp.MustCompile(`^[-+]?(\.[0-9]+|[0-9]+(\.[0-9]*)?)([eE][-+]?[0-9]+)?$`)
+	yamlInf     = regexp.MustCompile(`^([-+]?\.(inf|Inf|INF)|\.(nan|NaN|NAN))$`)
+)
+
+// It's a private function. It converts a scalar (or a flow sequence of scalars) to its
+// value, where null is nil.
+func yamlScalar(text string) (interface{}, error) {
+	if len(text) == 0 {
+		return nil, errors.New("The scalar is empty.")
+	}
+	switch text[0] {
+	case '"', '\'':
+		end := yamlQuoteEnd(text)
+		if end == -1 {
+			return nil, errors.New("The quoted scalar isn't properly ended.")
+		}
+		if end != len(text)-1 {
+			return nil, fmt.Errorf("Unexpected '%s' after the quoted scalar.", text[end+1:])
+		}
+		if text[0] == '\'' {
+			return strings.ReplaceAll(text[1:end], "''", "'"), nil
+		}
+		value, err := strconv.Unquote(text)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid escape in %s.", text)
+		}
+		return value, nil
+	case '[':
+		if text[len(text)-1] != ']' {
+			return nil, errors.New("The flow sequence isn't properly ended on the same line.")
+		}
+		var array []interface{}
+		rest := strings.TrimSpace(text[1 : len(text)-1])
+		for len(rest) > 0 {
+			end := strings.IndexByte(rest, ',')
+			if rest[0] == '"' || rest[0] == '\'' {
+				if end = yamlQuoteEnd(rest); end != -1 {
+					end = strings.IndexByte(rest[end:]+",", ',') + end
+				}
+			}
+			if end == -1 {
+				end = len(rest)
+			}
+			element := strings.TrimSpace(rest[:end])
+			if len(element) == 0 {
+				return nil, errors.New("Empty element in the flow sequence.")
+			}
+			value, err := yamlScalar(element)
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, value)
+			if end == len(rest) {
+				break
+			}
+			rest = strings.TrimSpace(rest[end+1:])
+		}
+		return array, nil
+	case '{':
+		return nil, errors.New("Flow mappings aren't supported.")
+	case '|', '>':
+		return nil, errors.New("Block scalars aren't supported.")
+	case '&', '*', '!':
+		return nil, errors.New("Anchors, aliases and tags aren't supported.")
+	}
+
+	switch text {
+	case "null", "Null", "NULL", "~":
+		return nil, nil
+	case "true", "True", "TRUE":
+		return true, nil
+	case "false", "False", "FALSE":
+		return false, nil
+	}
+	switch {
+	case yamlInf.MatchString(text):
+		return nil, errors.New("Non-finite numbers aren't allowed.")
+	case yamlOctal.MatchString(text):
+		value, err := strconv.ParseInt(text[2:], 8, 64)
+		return float64(value), err
+	case yamlHex.MatchString(text):
+		value, err := strconv.ParseInt(text[2:], 16, 64)
+		return float64(value), err
+	case yamlInteger.MatchString(text), yamlFloat.MatchString(text):
+		return strconv.ParseFloat(text, 64)
+	}
+	return text, nil
+}
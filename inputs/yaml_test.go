@@ -0,0 +1,132 @@
+package inputs
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseYAML(t *testing.T) {
+	input := `---
+# comment
+time:
+  tlim: 1.0 # comment
+  nlim: -1
+  "quoted key": 'it''s'
+mesh:
+    nx1: 64
+    x1min: -.5
+    hex: 0x1F
+    octal: 0o17
+    periodic: True
+    ix1_bc: "outflow # not a comment"
+    id: run#1
+    escapes: "tab\t\"q\""
+    plain: hello world
+output1:
+  variable: [rho, 'press', "a, b"]
+  empty: []
+  numbers: [1, 2.5, -3]
+  flags: [true, FALSE]
+...
+ignored: after the end
+`
+	var pin ParameterInput
+	if err := pin.LoadFromYAML([]byte(input)); err != nil {
+		t.Fatal(err)
+	}
+	checkParameters(t, &pin, map[string]interface{}{
+		"time/tlim":        1.0,
+		"time/nlim":        -1.0,
+		"time/quoted key":  "it's",
+		"mesh/nx1":         64.0,
+		"mesh/x1min":       -0.5,
+		"mesh/hex":         31.0,
+		"mesh/octal":       15.0,
+		"mesh/periodic":    true,
+		"mesh/ix1_bc":      "outflow # not a comment",
+		"mesh/id":          "run#1",
+		"mesh/escapes":     "tab\t\"q\"",
+		"mesh/plain":       "hello world",
+		"output1/variable": []string{"rho", "press", "a, b"},
+		"output1/empty":    []interface{}{},
+		"output1/numbers":  []float64{1, 2.5, -3},
+		"output1/flags":    []bool{true, false},
+	})
+	if names := pin.BlockNames(""); !reflect.DeepEqual(names, []string{"mesh", "output1", "time"}) {
+		t.Errorf("BlockNames = %v", names)
+	}
+	if source, _ := pin.Source("mesh", "hex"); source != "line 10" {
+		t.Errorf("source of mesh/hex = %q, want line 10", source)
+	}
+}
+
+func TestParseYAMLErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		line  int
+		msg   string
+	}{
+		{"tlim: 1", 1, "isn't in any block"},
+		{"  tlim: 1", 1, "Unexpected indentation"},
+		{"time:\n  tlim", 2, "isn't in the form 'key: value'"},
+		{"time:\n  tlim:", 2, "has no value"},
+		{"time:\n  tlim: 1\n  tlim: 2", 3, "defined twice"},
+		{"time:\ntime:", 2, "defined twice"},
+		{"time:\n  tlim: 1\n    nlim: 2", 3, "Nested mappings"},
+		{"time:\n    tlim: 1\n  nlim: 2", 3, "Inconsistent indentation"},
+		{"time:\n\ttlim: 1", 2, "Tabs"},
+		{"time:\n  - 1", 2, "Sequences"},
+		{"time:\n  tlim: .inf", 2, "Non-finite"},
+		{"time:\n  tlim: |", 2, "Block scalars"},
+		{"time:\n  tlim: &a 1", 2, "Anchors"},
+		{"time:\n  tlim: {a: 1}", 2, "Flow mappings"},
+		{"time:\n  tlim: [1, 2", 2, "isn't properly ended"},
+		{"time:\n  tlim: [1, , 2]", 2, "Empty element"},
+		{"time:\n  tlim: \"x", 2, "isn't properly ended"},
+		{"time:\n  tlim: 'x' y", 2, "Unexpected"},
+		{"---\ntime:\n  tlim: 1\n---\n", 4, "Multiple documents"},
+		{"\"@include\":\n  - 1\n", 2, "must be an array of file names"},
+		{"\"@include\":\n  -\n", 2, "must be an array of file names"},
+		{"@include:\n  - a.yaml\n  -\n", 3, "must be an array of file names"},
+	}
+	for _, test := range tests {
+		var pin ParameterInput
+		err := pin.LoadFromYAML([]byte(test.input))
+		checkSyntaxError(t, test.input, err, test.line, test.msg)
+		if len(pin.BlockNames("")) != 0 {
+			t.Errorf("%q: parameters are loaded despite the error", test.input)
+		}
+	}
+	for _, input := range []string{"time:\n  tlim: ~", "time:\n  tlim: null", "time:\n  tlim: [1, a]"} {
+		var pin ParameterInput
+		if err := pin.LoadFromYAML([]byte(input)); !errors.Is(err, ErrInput) {
+			t.Errorf("%q: got %v, want an input error", input, err)
+		}
+	}
+}
+
+func TestYAMLIncludes(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, text string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	write("a.toml", "[time]\ntlim = 1.0\n")
+	write("b.yml", "mesh:\n  nx1: 32\n")
+	for _, deck := range []string{
+		write("flow.yaml", "\"@include\": [a.toml, b.yml]\ntime:\n  nlim: 5\n"),
+		write("block.yaml", "\"@include\":\n  - a.toml\n  - 'b.yml'\ntime:\n  nlim: 5\n"),
+	} {
+		var pin ParameterInput
+		if err := pin.LoadFromFile(deck); err != nil {
+			t.Fatal(err)
+		}
+		checkParameters(t, &pin, map[string]interface{}{"time/tlim": 1.0, "time/nlim": 5.0, "mesh/nx1": 32.0})
+	}
+}
@@ -1,48 +1,96 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"io/ioutil"
+	"os"
+	"path/filepath"
 	"runtime"
 	"time"
 )
 
 import (
 	"gothena/inputs"
+	"gothena/mesh"
+	"gothena/outputs"
 	"gothena/utils"
 )
 
 var mbcnt uint64
 
+// Exit codes follow sysexits.h, so that a job scheduler can tell bad input from crashes
+// (an uncaught panic exits with 2).
+const (
+	EXIT_USAGE   = 64 // wrong command line usage
+	EXIT_DATAERR = 65 // bad input parameters or input file syntax
+	EXIT_NOINPUT = 66 // input or restart file doesn't exist or can't be read
+	EXIT_IOERR   = 74 // error while writing outputs or changing the run directory
+)
+
+// How often the overrides file given by -w is checked.
+const OVERRIDES_INTERVAL = time.Second
+
+//----------------------------------------------------------------------------------------
+//! \fn fatal(code int, err error)
+//! \brief prints a concise diagnostic message and terminates with the exit code
+
+func fatal(code int, err error) {
+	fmt.Fprintf(os.Stderr, "### FATAL ERROR in main\n%v\n", err)
+	os.Exit(code)
+}
+
+// It's a private function. It chooses the exit code for err returned while reading the
+// input files, where any error that isn't from the inputs package is an I/O error.
+func inputExitCode(err error) int {
+	if errors.Is(err, inputs.ErrInput) {
+		return EXIT_DATAERR
+	}
+	return EXIT_NOINPUT
+}
+
 func main() {
 
 	//--- Step 1. --------------------------------------------------------------------
 	// Set CPU number for parallel and check for command line options and respond.
 	runtime.GOMAXPROCS(runtime.NumCPU())
 
+	// "gothena sweep ..." generates the inputs of a parameter sweep instead of running.
+	if len(os.Args) > 1 && os.Args[1] == "sweep" {
+		runSweep(os.Args[2:])
+		return
+	}
+
 	// Input -h will automatically get the help menu
 	input_filename := flag.String("i", "", "specify input file [athinput]")
+	input_format := flag.String("f", "", "format of input file: json, athinput, toml or yaml [by extension]")
 	restart_filename := flag.String("r", "", "restart with this file")
-	// prundir := flag.String("d", "", "specify run dir [current dir]") TODO
-	// narg_flag := flag.Bool("n", false, "parse input file and quit") TODO
+	prundir := flag.String("d", "", "specify run dir [current dir]")
+	narg_flag := flag.Bool("n", false, "parse input file and quit")
 	config_flag := flag.Bool("c", false, "show configuration and quit")
 	// mesh_flag := flag.Int("m", 0, "output mesh structure and quit") TODO
 	// set to <nproc> if -m <nproc> argument is on cmdline
 	wtlim := flag.Duration("t", 0, "wall time limit for final output")
+	usage_filename := flag.String("u", "", "write unused/defaulted parameter report to file [stdout]")
+	overrides_filename := flag.String("w", "", "watch this file for changes of runtime parameters during the run")
 
 	flag.Parse()
 
 	if *config_flag { utils.ShowConfig(); return }
 
 	if *input_filename == "" && *restart_filename == "" {
-		panic("No input file or restart file is specified.")
+		fatal(EXIT_USAGE, errors.New("No input file or restart file is specified."))
+	}
+	if !inputs.IsFormat(*input_format) {
+		fatal(EXIT_USAGE, fmt.Errorf("Unknown input format %q.", *input_format))
 	}
 
-	// Set up the timer
-	if *wtlim != 0 {
-		timeout := make(chan bool)
-		go func() { time.Sleep(*wtlim); timeout <- true }()
+	// Set up the signal handler and the timer. The flags are only checked at the end of
+	// each cycle, so the run stops cleanly after the current cycle.
+	utils.SignalHandlerInit()
+	if *wtlim > 0 {
+		utils.SetWallTimeAlarm(*wtlim)
 	}
 
 	// my_rank? TODO
@@ -54,36 +102,213 @@ func main() {
 	var pinput inputs.ParameterInput
 
 	if *restart_filename != "" {
-		str, err := ioutil.ReadFile(*restart_filename)
-		if err != nil {
-			panic(err)
-		}
-		err = pinput.LoadFromByte(str)
+		err := pinput.LoadFromRestartFile(*restart_filename)
 		if err != nil {
-			panic(err)
+			fatal(inputExitCode(err), err)
 		}
 		// If both -r and -i are specified, make sure next_time gets corrected.
 		// This needs to be corrected on the restart file because we need the old dt.
 		if *input_filename != "" {
-			pinput.ChangeNextTime(-1)
+			if err := pinput.RollbackNextTime(); err != nil {
+				fatal(EXIT_DATAERR, err)
+			}
 		}
 	}
 	if *input_filename != "" {
-		// if both -r and -i are specified, override the parameters using the input file
-		str, err := ioutil.ReadFile(*input_filename)
+		// if both -r and -i are specified, override the parameters using the input file.
+		// The format is detected from the file unless it's given by -f.
+		err := pinput.LoadFromFileAs(*input_filename, *input_format)
 		if err != nil {
-			panic(err)
+			fatal(inputExitCode(err), err)
+		}
+	}
+	// Environment variables GOTHENA_block__name=value override both files, and parameters
+	// in the form block/name=value after the flags override everything.
+	if err := pinput.ModifyFromEnvironment(os.Environ()); err != nil {
+		fatal(EXIT_DATAERR, err)
+	}
+	if err := pinput.ModifyFromCmdline(flag.Args()); err != nil {
+		fatal(EXIT_DATAERR, err)
+	}
+	// Evaluate expressions once all the parameters are merged.
+	if err := pinput.EvaluateExpressions(); err != nil {
+		fatal(EXIT_DATAERR, err)
+	}
+	// Check the parameters against the schemas declared by the modules.
+	if err := pinput.Validate(); err != nil {
+		fatal(EXIT_DATAERR, err)
+	}
+
+	//--- Step 3. --------------------------------------------------------------------------
+	// Construct and initialize Mesh
+
+	pmesh, err := mesh.NewMesh(&pinput, *restart_filename != "")
+	if err != nil {
+		fatal(EXIT_DATAERR, err)
+	}
+
+	// With current mesh time possibly read from restart file, correct next_time for outputs
+	if *input_filename != "" && *restart_filename != "" {
+		// if both -r and -i are specified, ensure that next_time >= mesh_time - dt
+		if err := pinput.ForwardNextTime(pmesh.Time); err != nil {
+			fatal(EXIT_DATAERR, err)
 		}
-		err = pinput.LoadFromByte(str)
+	}
+
+	// Dump input parameters and quit if code was run with -n option. Any error above has
+	// already terminated the program with a non-zero exit code.
+	if *narg_flag {
+		fmt.Print(pinput.AthinputDump())
+		writeUsageReport(&pinput, *usage_filename)
+		return
+	}
+
+	//--- Step 6. --------------------------------------------------------------------------
+	// Change to run directory, initialize outputs object, and make output of ICs
+
+	// The overrides file is relative to the directory where the code is started.
+	if *overrides_filename != "" {
+		abs_name, err := filepath.Abs(*overrides_filename)
 		if err != nil {
-			panic(err)
+			fatal(EXIT_USAGE, err)
 		}
+		*overrides_filename = abs_name
+	}
+	if err := utils.ChangeRunDir(*prundir); err != nil {
+		fatal(EXIT_IOERR, err)
 	}
+	pouts, err := outputs.NewOutputs(pmesh, &pinput)
+	if err != nil {
+		fatal(EXIT_DATAERR, err)
+	}
+	if *restart_filename == "" {
+		if err := pouts.MakeOutputs(pmesh, &pinput, false); err != nil {
+			fatal(EXIT_IOERR, err)
+		}
+	}
+
+	//=== Step 7. === START OF MAIN INTEGRATION LOOP =======================================
 
-	fmt.Println(pinput.ParameterDump())
+	fmt.Print("\nSetup complete, entering main loop...\n\n")
+
+	// Changes of the overrides file are applied at the end of a cycle.
+	var pwatcher *utils.FileWatcher
+	if *overrides_filename != "" {
+		pwatcher = utils.WatchFile(*overrides_filename, OVERRIDES_INTERVAL)
+	}
+
+	for pmesh.Time < pmesh.Tlim && (pmesh.Nlim < 0 || pmesh.Ncycle < pmesh.Nlim) {
+		pmesh.OutputCycleDiagnostics()
+
+		pmesh.Ncycle++
+		pmesh.Time += pmesh.Dt
+
+		pmesh.NewTimeStep()
+
+		if pmesh.Time < pmesh.Tlim { // skip the final output as it happens later
+			if err := pouts.MakeOutputs(pmesh, &pinput, false); err != nil {
+				fatal(EXIT_IOERR, err)
+			}
+		}
+
+		// SIGUSR1 asks for a restart dump without stopping
+		if utils.CheckRestartFlag() {
+			if err := pouts.ForceRestart(pmesh, &pinput); err != nil {
+				fatal(EXIT_IOERR, err)
+			}
+		}
+
+		if pwatcher != nil && pwatcher.CheckModified() {
+			applyOverrides(*overrides_filename, &pinput, pmesh, pouts)
+		}
+
+		// check for signals
+		if utils.CheckSignalFlags() {
+			break
+		}
+	} // END OF MAIN INTEGRATION LOOP ======================================================
+	// Make final outputs, print diagnostics, clean up and terminate
+
+	if *wtlim > 0 {
+		utils.CancelWallTimeAlarm()
+	}
+	if pwatcher != nil {
+		pwatcher.Stop()
+	}
+
+	//--- Step 8. --------------------------------------------------------------------------
+	// Output the final cycle diagnostics and make the final outputs and print diagnostic
+	// messages related to the end of the simulation
+
+	pmesh.OutputCycleDiagnostics()
+
+	if err := pouts.MakeOutputs(pmesh, &pinput, utils.CheckSignalFlags()); err != nil {
+		fatal(EXIT_IOERR, err)
+	}
+
+	if utils.GetSignalFlag(utils.ITERM) {
+		fmt.Println("\nTerminating on Terminate signal")
+	} else if utils.GetSignalFlag(utils.IINT) {
+		fmt.Println("\nTerminating on Interrupt signal")
+	} else if utils.GetSignalFlag(utils.IALRM) {
+		fmt.Println("\nTerminating on wall-time limit")
+	} else if pmesh.Ncycle == pmesh.Nlim {
+		fmt.Println("\nTerminating on cycle limit")
+	} else {
+		fmt.Println("\nTerminating on time limit")
+	}
+	fmt.Printf("time=%v cycle=%d\n", pmesh.Time, pmesh.Ncycle)
+	fmt.Printf("tlim=%v nlim=%d\n", pmesh.Tlim, pmesh.Nlim)
+
+	fmt.Println()
+	writeUsageReport(&pinput, *usage_filename)
+}
+
+//----------------------------------------------------------------------------------------
+//! \fn writeUsageReport(pin *inputs.ParameterInput, filename string)
+//! \brief prints the report of unused and defaulted parameters, or writes it to filename
+//!
+//! The file is written relative to the run directory, like all the other outputs.
+
+func writeUsageReport(pin *inputs.ParameterInput, filename string) {
+	if filename == "" {
+		fmt.Print(pin.UsageReport())
+		return
+	}
+	if err := ioutil.WriteFile(filename, []byte(pin.UsageReport()), 0644); err != nil {
+		fatal(EXIT_IOERR, err)
+	}
+}
+
+// It's a private function. It applies the overrides file, and logs every change. An error
+// is only a warning, so that a typo doesn't stop a long run: the changes are rolled back
+// if the mesh or the outputs don't accept them.
+func applyOverrides(filename string, pin *inputs.ParameterInput, pm *mesh.Mesh, pouts *outputs.Outputs) {
+	changes, err := pin.ReloadFromFile(filename)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "### WARNING in main\nOverrides in %s are not applied:\n%v\n", filename, err)
+		return
+	}
+	if len(changes) == 0 {
+		return
+	}
+	err = pm.ReloadParameters(pin)
+	if err == nil {
+		err = pouts.ReloadParameters(pin)
+	}
+	if err != nil {
+		// The old values were accepted before, so reading them again can't fail.
+		pin.RevertChanges(changes)
+		pm.ReloadParameters(pin)
+		pouts.ReloadParameters(pin)
+		fmt.Fprintf(os.Stderr, "### WARNING in main\nOverrides in %s are rolled back:\n%v\n", filename, err)
+		return
+	}
+	for _, change := range changes {
+		fmt.Printf("Runtime parameter %v at cycle=%d time=%.14e\n", &change, pm.Ncycle, pm.Time)
+	}
 }
 
-// Modify from cmd line isn't surported.
 /*
 	//--- Step 3. --------------------------------------------------------------------------
 	// Construct and initialize Mesh
@@ -0,0 +1,187 @@
+package mesh
+
+import (
+	"fmt"
+	"math"
+)
+
+import "gothena/inputs"
+
+//----------------------------------------------------------------------------------------
+//! \struct Mesh
+//! \brief data/functions associated with the overall mesh
+//!
+//! Only the time-keeping part of Athena++ Mesh is ported. MeshBlocks, physics and task
+//! lists are still missing, so a cycle only advances the time.
+
+type Mesh struct {
+	StartTime, Time, Tlim, Dt float64
+	Nlim, Ncycle              int
+	ncycle_out                int
+	cfl_number                float64
+	dx_min                    float64
+}
+
+// Schemas of <time> and <mesh> blocks, following the parameters read by Athena++ Mesh.
+func init() {
+	inputs.RegisterSchema("time", inputs.BlockSchema{Parameters: map[string]inputs.ParameterSchema{
+		"cfl_number":       {Type: inputs.TYPE_REAL, Min: inputs.Bound(0)},
+		"nlim":             {Type: inputs.TYPE_INTEGER, Min: inputs.Bound(-1)},
+		"tlim":             {Type: inputs.TYPE_REAL},
+		"start_time":       {Type: inputs.TYPE_REAL},
+		"integrator":       {Type: inputs.TYPE_STRING, Enum: []string{"rk1", "vl2", "rk2", "rk3", "rk4", "ssprk5_4"}},
+		"xorder":           {Enum: []string{"1", "2", "2c", "3", "3c", "4"}},
+		"ncycle_out":       {Type: inputs.TYPE_INTEGER, Min: inputs.Bound(0)},
+		"dt_diagnostics":   {Type: inputs.TYPE_INTEGER},
+		"sts_integrator":   {Type: inputs.TYPE_STRING, Enum: []string{"rkl1", "rkl2"}},
+		"sts_max_dt_ratio": {Type: inputs.TYPE_REAL},
+	}})
+
+	bc := []string{"reflecting", "outflow", "user", "periodic", "polar", "polar_wedge", "shear_periodic"}
+	mesh_schema := inputs.BlockSchema{Parameters: map[string]inputs.ParameterSchema{
+		"num_threads":    {Type: inputs.TYPE_INTEGER, Min: inputs.Bound(1)},
+		"refinement":     {Type: inputs.TYPE_STRING, Enum: []string{"none", "static", "adaptive"}},
+		"numlevel":       {Type: inputs.TYPE_INTEGER, Min: inputs.Bound(1)},
+		"derefine_count": {Type: inputs.TYPE_INTEGER, Min: inputs.Bound(1)},
+	}}
+	for _, n := range []string{"1", "2", "3"} {
+		mesh_schema.Parameters["nx"+n] = inputs.ParameterSchema{Type: inputs.TYPE_INTEGER, Min: inputs.Bound(1)}
+		mesh_schema.Parameters["x"+n+"min"] = inputs.ParameterSchema{Type: inputs.TYPE_REAL}
+		mesh_schema.Parameters["x"+n+"max"] = inputs.ParameterSchema{Type: inputs.TYPE_REAL}
+		mesh_schema.Parameters["x"+n+"rat"] = inputs.ParameterSchema{Type: inputs.TYPE_REAL, Min: inputs.Bound(0)}
+		mesh_schema.Parameters["ix"+n+"_bc"] = inputs.ParameterSchema{Type: inputs.TYPE_STRING, Enum: bc}
+		mesh_schema.Parameters["ox"+n+"_bc"] = inputs.ParameterSchema{Type: inputs.TYPE_STRING, Enum: bc}
+	}
+	inputs.RegisterSchema("mesh", mesh_schema)
+
+	// Written by restart outputs and read back by NewMesh.
+	inputs.RegisterSchema("restart", inputs.BlockSchema{Parameters: map[string]inputs.ParameterSchema{
+		"time":   {Type: inputs.TYPE_REAL},
+		"dt":     {Type: inputs.TYPE_REAL, Min: inputs.Bound(0)},
+		"ncycle": {Type: inputs.TYPE_INTEGER, Min: inputs.Bound(0)},
+	}})
+}
+
+//----------------------------------------------------------------------------------------
+//! \fn (*Mesh, error) NewMesh(pin *inputs.ParameterInput, res_flag bool)
+//! \brief Mesh constructor, builds mesh at start of calculation using parameters in
+//! input file, or restores it from the "restart" block of a restart file if res_flag
+
+func NewMesh(pin *inputs.ParameterInput, res_flag bool) (*Mesh, error) {
+	this := new(Mesh)
+	var err error
+	if this.StartTime, err = pin.GetOrAddReal("time", "start_time", 0.0); err != nil {
+		return nil, err
+	}
+	if this.Tlim, err = pin.GetReal("time", "tlim"); err != nil {
+		return nil, err
+	}
+	if this.Nlim, err = pin.GetOrAddInteger("time", "nlim", -1); err != nil {
+		return nil, err
+	}
+	if this.ncycle_out, err = pin.GetOrAddInteger("time", "ncycle_out", 1); err != nil {
+		return nil, err
+	}
+	if this.cfl_number, err = pin.GetReal("time", "cfl_number"); err != nil {
+		return nil, err
+	}
+	if this.dx_min, err = minCellWidth(pin); err != nil {
+		return nil, err
+	}
+
+	if res_flag {
+		if this.Time, err = pin.GetReal("restart", "time"); err != nil {
+			return nil, err
+		}
+		if this.Dt, err = pin.GetReal("restart", "dt"); err != nil {
+			return nil, err
+		}
+		if this.Ncycle, err = pin.GetInteger("restart", "ncycle"); err != nil {
+			return nil, err
+		}
+	} else {
+		this.Time = this.StartTime
+		this.NewTimeStep()
+	}
+	return this, nil
+}
+
+// It's a private function. It returns the smallest cell width of the root grid, where
+// dimensions with nx = 1 are ignored.
+func minCellWidth(pin *inputs.ParameterInput) (float64, error) {
+	dx := math.Inf(1)
+	for _, n := range []string{"1", "2", "3"} {
+		var nx int
+		var err error
+		if n == "1" {
+			nx, err = pin.GetInteger("mesh", "nx1")
+		} else {
+			nx, err = pin.GetOrAddInteger("mesh", "nx"+n, 1)
+		}
+		if err != nil {
+			return 0, err
+		}
+		if nx < 1 {
+			return 0, &inputs.ParameterValueError{Block: "mesh", Name: "nx" + n, Value: nx,
+				Reason: "must be positive"}
+		}
+		if nx == 1 && n != "1" {
+			continue
+		}
+		xmin, err := pin.GetReal("mesh", "x"+n+"min")
+		if err != nil {
+			return 0, err
+		}
+		xmax, err := pin.GetReal("mesh", "x"+n+"max")
+		if err != nil {
+			return 0, err
+		}
+		if xmax <= xmin {
+			return 0, &inputs.ParameterValueError{Block: "mesh", Name: "x" + n + "max", Value: xmax,
+				Reason: "must be larger than x" + n + "min"}
+		}
+		dx = math.Min(dx, (xmax-xmin)/float64(nx))
+	}
+	return dx, nil
+}
+
+//----------------------------------------------------------------------------------------
+//! \fn Mesh.NewTimeStep()
+//! \brief function that loops over all MeshBlocks and find new timestep
+//!
+//! No physics is ported yet, so the maximum signal speed is taken as 1 and the time step
+//! is the CFL number times the smallest cell width. The last step is shortened to hit tlim.
+
+func (this *Mesh) NewTimeStep() {
+	this.Dt = this.cfl_number * this.dx_min
+	if this.Time < this.Tlim && this.Tlim-this.Time < this.Dt {
+		this.Dt = this.Tlim - this.Time
+	}
+}
+
+//----------------------------------------------------------------------------------------
+//! \fn error Mesh.ReloadParameters(pin *inputs.ParameterInput)
+//! \brief reads tlim and nlim again after they are changed during the run
+
+func (this *Mesh) ReloadParameters(pin *inputs.ParameterInput) error {
+	tlim, err := pin.GetReal("time", "tlim")
+	if err != nil {
+		return err
+	}
+	nlim, err := pin.GetInteger("time", "nlim")
+	if err != nil {
+		return err
+	}
+	this.Tlim, this.Nlim = tlim, nlim
+	return nil
+}
+
+//----------------------------------------------------------------------------------------
+//! \fn Mesh.OutputCycleDiagnostics()
+//! \brief prints the time step diagnostics every ncycle_out cycles
+
+func (this *Mesh) OutputCycleDiagnostics() {
+	if this.ncycle_out > 0 && this.Ncycle%this.ncycle_out == 0 {
+		fmt.Printf("cycle=%d time=%.14e dt=%.14e\n", this.Ncycle, this.Time, this.Dt)
+	}
+}
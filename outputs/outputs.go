@@ -0,0 +1,202 @@
+package outputs
+
+import (
+	"fmt"
+	"io/ioutil"
+)
+
+import (
+	"gothena/inputs"
+	"gothena/mesh"
+)
+
+//----------------------------------------------------------------------------------------
+//! \struct OutputParameters
+//! \brief  container for parameters read from <output> block in the input file
+
+type OutputParameters struct {
+	block_name    string
+	file_basename string
+	file_type     string
+	file_number   int
+	next_time, dt float64
+}
+
+//----------------------------------------------------------------------------------------
+//! \struct Outputs
+//! \brief root class for all Athena++ outputs. Provides a list of all outputs
+
+type Outputs struct {
+	output_list []OutputParameters
+}
+
+// Schemas of <job> and <output[n]> blocks, following the parameters read by Athena++.
+func init() {
+	inputs.RegisterSchema("job", inputs.BlockSchema{Parameters: map[string]inputs.ParameterSchema{
+		"problem_id": {Type: inputs.TYPE_STRING},
+	}})
+	inputs.RegisterSchema("output*", inputs.BlockSchema{Parameters: map[string]inputs.ParameterSchema{
+		"file_type":        {Type: inputs.TYPE_STRING, Enum: []string{"hst", "tab", "vtk", "hdf5", "ath5", "rst"}},
+		"dt":               {Type: inputs.TYPE_REAL, Min: inputs.Bound(0), MinExclusive: true},
+		"dcycle":           {Type: inputs.TYPE_INTEGER, Min: inputs.Bound(1)},
+		"next_time":        {Type: inputs.TYPE_REAL},
+		"file_number":      {Type: inputs.TYPE_INTEGER, Min: inputs.Bound(0)},
+		"variable":         {Type: inputs.TYPE_STRING},
+		"id":               {Type: inputs.TYPE_STRING},
+		"data_format":      {Type: inputs.TYPE_STRING},
+		"ghost_zones":      {Type: inputs.TYPE_BOOLEAN},
+		"cartesian_vector": {Type: inputs.TYPE_BOOLEAN},
+		"x1_slice":         {Type: inputs.TYPE_REAL},
+		"x2_slice":         {Type: inputs.TYPE_REAL},
+		"x3_slice":         {Type: inputs.TYPE_REAL},
+		"x1_sum":           {Type: inputs.TYPE_BOOLEAN},
+		"x2_sum":           {Type: inputs.TYPE_BOOLEAN},
+		"x3_sum":           {Type: inputs.TYPE_BOOLEAN},
+		"xdmf":             {Type: inputs.TYPE_INTEGER, Min: inputs.Bound(0), Max: inputs.Bound(1)},
+	}})
+}
+
+//----------------------------------------------------------------------------------------
+//! \fn (*Outputs, error) NewOutputs(pm *mesh.Mesh, pin *inputs.ParameterInput)
+//! \brief Outputs constructor, reads every <output[n]> block in the input file
+//!
+//! Only restart ("rst") outputs are ported. Other file types are skipped with a warning.
+
+func NewOutputs(pm *mesh.Mesh, pin *inputs.ParameterInput) (*Outputs, error) {
+	this := new(Outputs)
+	for _, block_name := range pin.BlockNames("output") {
+		var op OutputParameters
+		var err error
+		op.block_name = block_name
+		if op.file_type, err = pin.GetString(block_name, "file_type"); err != nil {
+			return nil, err
+		}
+		if op.file_type != "rst" {
+			fmt.Printf("Output Warning: file_type %s in block %s isn't supported yet, skipped.\n",
+				op.file_type, block_name)
+			continue
+		}
+		if op.dt, err = pin.GetReal(block_name, "dt"); err != nil {
+			return nil, err
+		}
+		if op.dt <= 0 {
+			return nil, &inputs.ParameterValueError{Block: block_name, Name: "dt", Value: op.dt,
+				Reason: "must be positive"}
+		}
+		if op.next_time, err = pin.GetOrAddReal(block_name, "next_time", pm.Time); err != nil {
+			return nil, err
+		}
+		if op.file_number, err = pin.GetOrAddInteger(block_name, "file_number", 0); err != nil {
+			return nil, err
+		}
+		if op.file_basename, err = pin.GetString("job", "problem_id"); err != nil {
+			return nil, err
+		}
+		this.output_list = append(this.output_list, op)
+	}
+	return this, nil
+}
+
+//----------------------------------------------------------------------------------------
+//! \fn error Outputs.ReloadParameters(pin *inputs.ParameterInput)
+//! \brief reads dt of every output again after it is changed during the run
+//!
+//! The next output is still written at the scheduled next_time, and the following ones
+//! are spaced by the new dt. Nothing is changed if any error is found.
+
+func (this *Outputs) ReloadParameters(pin *inputs.ParameterInput) error {
+	dts := make([]float64, len(this.output_list))
+	for i, op := range this.output_list {
+		dt, err := pin.GetReal(op.block_name, "dt")
+		if err != nil {
+			return err
+		}
+		if dt <= 0 {
+			return &inputs.ParameterValueError{Block: op.block_name, Name: "dt", Value: dt,
+				Reason: "must be positive"}
+		}
+		dts[i] = dt
+	}
+	for i := range this.output_list {
+		this.output_list[i].dt = dts[i]
+	}
+	return nil
+}
+
+//----------------------------------------------------------------------------------------
+//! \fn error Outputs.MakeOutputs(pm *mesh.Mesh, pin *inputs.ParameterInput, wtflag bool)
+//! \brief scans through linked list of OutputTypes and makes any outputs needed.
+//!
+//! If wtflag is true (the run is terminated by the wall-time limit or a signal), a final
+//! restart file is always written, even if there is no restart output block in the input.
+
+func (this *Outputs) MakeOutputs(pm *mesh.Mesh, pin *inputs.ParameterInput, wtflag bool) error {
+	wrote_restart := false
+	for i := range this.output_list {
+		op := &this.output_list[i]
+		if pm.Time == pm.StartTime || pm.Time >= op.next_time || pm.Time >= pm.Tlim ||
+			(wtflag && op.file_type == "rst") {
+			id := ""
+			if wtflag {
+				id = "final"
+			}
+			if err := op.writeRestartFile(pm, pin, id); err != nil {
+				return err
+			}
+			wrote_restart = true
+		}
+	}
+	if wtflag && !wrote_restart {
+		return this.writeExtraRestart(pm, pin, "final")
+	}
+	return nil
+}
+
+//----------------------------------------------------------------------------------------
+//! \fn error Outputs.ForceRestart(pm *mesh.Mesh, pin *inputs.ParameterInput)
+//! \brief writes a restart dump immediately (on SIGUSR1) into <problem_id>.usr1.rst
+//!
+//! The file is overwritten by each request, and the regular outputs are not affected.
+
+func (this *Outputs) ForceRestart(pm *mesh.Mesh, pin *inputs.ParameterInput) error {
+	return this.writeExtraRestart(pm, pin, "usr1")
+}
+
+// It's a private function. It writes a restart file named by id, which doesn't belong to
+// any output block.
+func (this *Outputs) writeExtraRestart(pm *mesh.Mesh, pin *inputs.ParameterInput, id string) error {
+	op := OutputParameters{file_type: "rst"}
+	var err error
+	if op.file_basename, err = pin.GetString("job", "problem_id"); err != nil {
+		return err
+	}
+	return op.writeRestartFile(pm, pin, id)
+}
+
+// It's a private function. Restart files are the JSON dump of all parameters, with the
+// state of the Mesh stored in the "restart" block. A non-empty id replaces the file number
+// in the file name, and the output block isn't updated.
+func (this *OutputParameters) writeRestartFile(pm *mesh.Mesh, pin *inputs.ParameterInput, id string) error {
+	fname := fmt.Sprintf("%s.%05d.rst", this.file_basename, this.file_number)
+	if id != "" {
+		fname = this.file_basename + "." + id + ".rst"
+	}
+
+	// Update the output parameters before dump, so that the restarted run continues.
+	if this.block_name != "" && id == "" {
+		this.file_number++
+		if pm.Time >= this.next_time {
+			this.next_time += this.dt
+		}
+		pin.SetParameter(this.block_name, "file_number", float64(this.file_number))
+		pin.SetParameter(this.block_name, "next_time", this.next_time)
+	}
+	pin.SetParameter("restart", "time", pm.Time)
+	pin.SetParameter("restart", "dt", pm.Dt)
+	pin.SetParameter("restart", "ncycle", float64(pm.Ncycle))
+
+	if err := ioutil.WriteFile(fname, []byte(pin.ParameterDump()), 0644); err != nil {
+		return fmt.Errorf("Output Error: Cannot write restart file '%s': %v", fname, err)
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+import (
+	"gothena/inputs"
+)
+
+const (
+	SWEEP_DECK     = "deck.json"
+	SWEEP_MANIFEST = "manifest.json"
+)
+
+// It's a private type. It collects a flag given several times.
+type stringList []string
+
+func (this *stringList) String() string { return strings.Join(*this, " ") }
+
+func (this *stringList) Set(value string) error {
+	*this = append(*this, value)
+	return nil
+}
+
+// It's a private type. It's a member of the sweep in the manifest.
+type sweepMember struct {
+	Name   string                 `json:"name"`
+	RunDir string                 `json:"run_dir"`
+	Deck   string                 `json:"deck"`
+	Values map[string]interface{} `json:"values"`
+}
+
+// It's a private type. It's the manifest of a sweep, written into its directory.
+type sweepManifest struct {
+	Base       string        `json:"base"`
+	Mode       string        `json:"mode"`
+	Parameters []string      `json:"parameters"`
+	Members    []sweepMember `json:"members"`
+}
+
+//----------------------------------------------------------------------------------------
+//! \fn runSweep(args []string)
+//! \brief the "sweep" subcommand, generating the inputs of an ensemble of runs
+//!
+//! gothena sweep -i base -s block/name=a,b,c -s block/name=start:stop:step [-mode zip]
+//!               -o dir [block/name=value ...]
+//! The base input is loaded as by the main command, then each member gets its swept
+//! values. For each member, dir/member_NNNN/deck.json is written, which is run by
+//! "gothena -i dir/member_NNNN/deck.json -d dir/member_NNNN", and dir/manifest.json lists
+//! the values of all members. Every member is checked (expressions and schemas) before
+//! anything is written.
+
+func runSweep(args []string) {
+	flags := flag.NewFlagSet("sweep", flag.ExitOnError)
+	input_filename := flags.String("i", "", "specify base input file")
+	input_format := flags.String("f", "", "format of input file: json, athinput, toml or yaml [by extension]")
+	var specs_flag stringList
+	flags.Var(&specs_flag, "s", "sweep block/name=a,b,c or block/name=start:stop:step (repeatable)")
+	mode := flags.String("mode", inputs.SWEEP_PRODUCT, "combine the values by product or zip")
+	out_dir := flags.String("o", "", "directory of the members")
+	flags.Parse(args)
+
+	if *input_filename == "" || *out_dir == "" {
+		fatal(EXIT_USAGE, errors.New("sweep needs a base input file (-i) and a directory (-o)."))
+	}
+	if !inputs.IsFormat(*input_format) {
+		fatal(EXIT_USAGE, fmt.Errorf("Unknown input format %q.", *input_format))
+	}
+	var specs []inputs.SweepSpec
+	for _, spec_str := range specs_flag {
+		spec, err := inputs.ParseSweepSpec(spec_str)
+		if err != nil {
+			fatal(EXIT_USAGE, err)
+		}
+		specs = append(specs, spec)
+	}
+	members, err := inputs.SweepMembers(specs, *mode)
+	if err != nil {
+		fatal(EXIT_USAGE, err)
+	}
+
+	var base inputs.ParameterInput
+	if err := base.LoadFromFileAs(*input_filename, *input_format); err != nil {
+		fatal(inputExitCode(err), err)
+	}
+	if err := base.ModifyFromCmdline(flags.Args()); err != nil {
+		fatal(EXIT_DATAERR, err)
+	}
+
+	// Build and check every member before writing anything.
+	manifest := sweepManifest{Base: *input_filename, Mode: *mode}
+	for _, spec := range specs {
+		manifest.Parameters = append(manifest.Parameters, spec.Block+"/"+spec.Name)
+	}
+	decks := make([]string, len(members))
+	for i, values := range members {
+		pin := base.Clone()
+		if err := pin.ApplySweep(specs, values); err != nil {
+			fatal(EXIT_DATAERR, err)
+		}
+		decks[i] = pin.ParameterDump() + "\n"
+		if err := pin.EvaluateExpressions(); err != nil {
+			fatal(EXIT_DATAERR, err)
+		}
+		if err := pin.Validate(); err != nil {
+			fatal(EXIT_DATAERR, err)
+		}
+		name := fmt.Sprintf("member_%04d", i)
+		member := sweepMember{Name: name, RunDir: name, Deck: filepath.Join(name, SWEEP_DECK),
+			Values: make(map[string]interface{})}
+		for j, key := range manifest.Parameters {
+			member.Values[key] = values[j]
+		}
+		manifest.Members = append(manifest.Members, member)
+	}
+
+	for i, member := range manifest.Members {
+		if err := os.MkdirAll(filepath.Join(*out_dir, member.RunDir), 0775); err != nil {
+			fatal(EXIT_IOERR, err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(*out_dir, member.Deck), []byte(decks[i]), 0644); err != nil {
+			fatal(EXIT_IOERR, err)
+		}
+	}
+	result, _ := json.MarshalIndent(manifest, "", "    ")
+	if err := ioutil.WriteFile(filepath.Join(*out_dir, SWEEP_MANIFEST), append(result, '\n'), 0644); err != nil {
+		fatal(EXIT_IOERR, err)
+	}
+	fmt.Printf("Wrote %d members of the sweep into %s\n", len(manifest.Members), *out_dir)
+}
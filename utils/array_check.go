@@ -0,0 +1,7 @@
+//go:build !debug
+
+package utils
+
+// Bounds checks of the fixed-arity accessors (At1, Set1, ...) are only compiled into a
+// debug build, made with "go build -tags debug".
+const ARRAY_BOUNDS_CHECK = false
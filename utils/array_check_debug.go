@@ -0,0 +1,6 @@
+//go:build debug
+
+package utils
+
+// The fixed-arity accessors (At1, Set1, ...) check their indices in a debug build.
+const ARRAY_BOUNDS_CHECK = true
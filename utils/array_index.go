@@ -0,0 +1,91 @@
+package utils
+
+// Fixed-arity accessors for the hot loops of numerical kernels. Unlike Get and Set, they
+// neither allocate nor return errors, and the indices are given in the same order as Get,
+// e.g. At3(k, j, i) where i is the first (fastest) dimension. The number of indices must
+// equal the number of dimensions of the array. Only a debug build (ARRAY_BOUNDS_CHECK)
+// checks the indices and panics with an error naming the dimension; otherwise an index
+// out of range silently reads another element, unless it's out of the whole data.
+
+func (this *Array[T]) At1(i int) T {
+	if ARRAY_BOUNDS_CHECK {
+		this.checkIndex(i)
+	}
+	return this.pdata_[i]
+}
+
+func (this *Array[T]) At2(j, i int) T {
+	if ARRAY_BOUNDS_CHECK {
+		this.checkIndex(j, i)
+	}
+	return this.pdata_[j*this.dim_num[0]+i]
+}
+
+func (this *Array[T]) At3(k, j, i int) T {
+	if ARRAY_BOUNDS_CHECK {
+		this.checkIndex(k, j, i)
+	}
+	d := this.dim_num
+	return this.pdata_[(k*d[1]+j)*d[0]+i]
+}
+
+func (this *Array[T]) At4(n, k, j, i int) T {
+	if ARRAY_BOUNDS_CHECK {
+		this.checkIndex(n, k, j, i)
+	}
+	d := this.dim_num
+	return this.pdata_[((n*d[2]+k)*d[1]+j)*d[0]+i]
+}
+
+func (this *Array[T]) At5(m, n, k, j, i int) T {
+	if ARRAY_BOUNDS_CHECK {
+		this.checkIndex(m, n, k, j, i)
+	}
+	d := this.dim_num
+	return this.pdata_[(((m*d[3]+n)*d[2]+k)*d[1]+j)*d[0]+i]
+}
+
+func (this *Array[T]) Set1(value T, i int) {
+	if ARRAY_BOUNDS_CHECK {
+		this.checkIndex(i)
+	}
+	this.pdata_[i] = value
+}
+
+func (this *Array[T]) Set2(value T, j, i int) {
+	if ARRAY_BOUNDS_CHECK {
+		this.checkIndex(j, i)
+	}
+	this.pdata_[j*this.dim_num[0]+i] = value
+}
+
+func (this *Array[T]) Set3(value T, k, j, i int) {
+	if ARRAY_BOUNDS_CHECK {
+		this.checkIndex(k, j, i)
+	}
+	d := this.dim_num
+	this.pdata_[(k*d[1]+j)*d[0]+i] = value
+}
+
+func (this *Array[T]) Set4(value T, n, k, j, i int) {
+	if ARRAY_BOUNDS_CHECK {
+		this.checkIndex(n, k, j, i)
+	}
+	d := this.dim_num
+	this.pdata_[((n*d[2]+k)*d[1]+j)*d[0]+i] = value
+}
+
+func (this *Array[T]) Set5(value T, m, n, k, j, i int) {
+	if ARRAY_BOUNDS_CHECK {
+		this.checkIndex(m, n, k, j, i)
+	}
+	d := this.dim_num
+	this.pdata_[(((m*d[3]+n)*d[2]+k)*d[1]+j)*d[0]+i] = value
+}
+
+// It's a private function. It panics if the indices don't fit the array.
+func (this *Array[T]) checkIndex(ij ...int) {
+	if err := this.indexError(ij); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,192 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Element-wise operations and reductions. The arithmetic is only defined for numeric
+// types, so it's given by functions, since a method of Array[T any] can't constrain T.
+// Each one works on the whole array, or on a sub-range given as an optional IndexRange.
+// The arrays of an operation must have the same dimensions, and the same sub-range is
+// used for all of them.
+
+type Number interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr |
+		~float32 | ~float64
+}
+
+// A sub-range of an array, with the indices in the same order as Get, e.g. {k, j, i}.
+// Both bounds are included, like the loops il..iu of Athena++.
+type IndexRange struct {
+	Lower, Upper []int
+}
+
+// y += a*x
+func AXPY[T Number](a T, x Array[T], y *Array[T], r ...IndexRange) error {
+	return zipRange(x, y, r, func(xv T, yv *T) { *yv += a * xv })
+}
+
+// x *= a
+func Scale[T Number](a T, x *Array[T], r ...IndexRange) error {
+	return x.forRange(r, func(offset int) { x.pdata_[offset] *= a })
+}
+
+// y += x
+func Add[T Number](x Array[T], y *Array[T], r ...IndexRange) error {
+	return zipRange(x, y, r, func(xv T, yv *T) { *yv += xv })
+}
+
+// y *= x
+func Multiply[T Number](x Array[T], y *Array[T], r ...IndexRange) error {
+	return zipRange(x, y, r, func(xv T, yv *T) { *yv *= xv })
+}
+
+func (this *Array[T]) Fill(value T, r ...IndexRange) error {
+	return this.forRange(r, func(offset int) { this.pdata_[offset] = value })
+}
+
+// Copies the elements of other into this, which must have the same dimensions. Unlike
+// DeepCopyArray, the data of this is kept, so it works for shallow slices.
+func (this *Array[T]) CopyFrom(other Array[T], r ...IndexRange) error {
+	if err := this.sameDims(other); err != nil {
+		return err
+	}
+	return this.forRange(r, func(offset int) { this.pdata_[offset] = other.pdata_[offset] })
+}
+
+func Sum[T Number](x Array[T], r ...IndexRange) (T, error) {
+	var sum T
+	err := x.forRange(r, func(offset int) { sum += x.pdata_[offset] })
+	return sum, err
+}
+
+// The minimum of the elements, which is an error for an empty array. NaN is ignored,
+// unless all the elements are NaN.
+func Min[T Number](x Array[T], r ...IndexRange) (T, error) {
+	return extremum(x, r, func(a, b T) bool { return a < b })
+}
+
+// The maximum of the elements, which is an error for an empty array. NaN is ignored,
+// unless all the elements are NaN.
+func Max[T Number](x Array[T], r ...IndexRange) (T, error) {
+	return extremum(x, r, func(a, b T) bool { return a > b })
+}
+
+func L1Norm[T Number](x Array[T], r ...IndexRange) (float64, error) {
+	var sum float64
+	err := x.forRange(r, func(offset int) { sum += math.Abs(float64(x.pdata_[offset])) })
+	return sum, err
+}
+
+func L2Norm[T Number](x Array[T], r ...IndexRange) (float64, error) {
+	var sum float64
+	err := x.forRange(r, func(offset int) {
+		value := float64(x.pdata_[offset])
+		sum += value * value
+	})
+	return math.Sqrt(sum), err
+}
+
+func Dot[T Number](x Array[T], y Array[T], r ...IndexRange) (T, error) {
+	var sum T
+	err := zipRange(x, &y, r, func(xv T, yv *T) { sum += xv * *yv })
+	return sum, err
+}
+
+// It's a private function. It calls f for the elements of x and y at the same position.
+func zipRange[T any](x Array[T], y *Array[T], r []IndexRange, f func(xv T, yv *T)) error {
+	if err := y.sameDims(x); err != nil {
+		return err
+	}
+	return y.forRange(r, func(offset int) { f(x.pdata_[offset], &y.pdata_[offset]) })
+}
+
+// It's a private function.
+func extremum[T Number](x Array[T], r []IndexRange, better func(a, b T) bool) (T, error) {
+	var result T
+	found := false
+	err := x.forRange(r, func(offset int) {
+		value := x.pdata_[offset]
+		// value != value is only true for NaN.
+		if !found || result != result || better(value, result) {
+			result, found = value, true
+		}
+	})
+	if err == nil && !found {
+		err = errors.New("Array Operation Error: The array is empty.")
+	}
+	return result, err
+}
+
+// It's a private function. It returns an error if other doesn't have the dimensions of
+// this.
+func (this *Array[T]) sameDims(other Array[T]) error {
+	if len(this.dim_num) == len(other.dim_num) {
+		same := true
+		for i := range this.dim_num {
+			same = same && this.dim_num[i] == other.dim_num[i]
+		}
+		if same && len(this.pdata_) == len(other.pdata_) {
+			return nil
+		}
+	}
+	return fmt.Errorf("Array Operation Error: Dimensions %v and %v don't match.", this.dim_num, other.dim_num)
+}
+
+// It's a private function. It calls f with the offset in pdata_ of each element in the
+// sub-range (at most one), or in the whole array.
+func (this *Array[T]) forRange(r []IndexRange, f func(offset int)) error {
+	if len(r) > 1 {
+		return errors.New("Array Operation Error: More than one sub-range is given.")
+	}
+	if len(r) == 0 {
+		for offset := range this.pdata_ {
+			f(offset)
+		}
+		return nil
+	}
+	lower, upper := r[0].Lower, r[0].Upper
+	if len(lower) != len(this.dim_num) || len(upper) != len(this.dim_num) {
+		return fmt.Errorf("Array Operation Error: The sub-range has %d and %d indices for a %d-dimensional array.",
+			len(lower), len(upper), len(this.dim_num))
+	}
+	if this.GetSize() == 0 {
+		return errors.New("Array Operation Error: The sub-range is out of the empty array.")
+	}
+	for i := range lower {
+		nx := this.dim_num[len(lower)-1-i]
+		if lower[i] < 0 || upper[i] >= nx || lower[i] > upper[i] {
+			return fmt.Errorf("Array Operation Error: Sub-range [%d, %d] of dimension %d is out of range [0, %d).",
+				lower[i], upper[i], len(lower)-i, nx)
+		}
+	}
+
+	// The last index is the fastest one, so each run of it is contiguous. The other
+	// indices are incremented like an odometer.
+	ndim := len(this.dim_num)
+	index := make([]int, ndim)
+	copy(index, lower)
+	for {
+		begin, err := this.access(index)
+		if err != nil {
+			return err
+		}
+		for offset := begin; offset <= begin+upper[ndim-1]-lower[ndim-1]; offset++ {
+			f(offset)
+		}
+		i := ndim - 2
+		for ; i >= 0; i-- {
+			if index[i] < upper[i] {
+				index[i]++
+				break
+			}
+			index[i] = lower[i]
+		}
+		if i < 0 {
+			return nil
+		}
+	}
+}
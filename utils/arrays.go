@@ -6,21 +6,72 @@ import "fmt"
 // be marked using shallow copy tag as Athena++ did. So it's your duty to
 // avoid shallow copy. And it's also your duty to avoid two goroutine write
 // at the same time (concurrency).
+//
+// Shallow slices (views) made by ShallowSliceArray and ShallowCopyArray are marked, and
+// share the data with their source: writing through a view changes the source and every
+// other view of it, and the other way round. A view keeps the whole source data alive for
+// the garbage collector. Its dimensions are its own, so changing them doesn't change the
+// source. Use DeepCopyArray to get an independent copy.
 
 type Array[T any] struct {
 	pdata_  []T
 	dim_num []int
+	shallow bool
 }
 
+// The dimensions are given from the first (fastest) one, e.g. AthenaArray[float64](nx1,
+// nx2, nx3), which is the reverse of NewAthenaArray(nx3, nx2, nx1) in Athena++. Get, Set
+// and the At/Set accessors take the indices in the order of Athena++, e.g. Get(k, j, i)
+// where i is in [0, nx1).
 func AthenaArray[T any](nx ...int) Array[T] {
 	var this Array[T]
+	this.NewAthenaArray(nx...)
+	return this
+}
+
+// Allocates new zeroed data with the dimensions nx, dropping the old data (of a shallow
+// slice, only the view is dropped). Arrays sharing the old data keep it.
+func (this *Array[T]) NewAthenaArray(nx ...int) {
 	data_num := 1
 	for _, i := range nx {
-		this.dim_num = append(this.dim_num, i)
 		data_num *= i
 	}
+	this.dim_num = make([]int, len(nx))
+	copy(this.dim_num, nx)
 	this.pdata_ = make([]T, data_num)
-	return this
+	this.shallow = false
+}
+
+// Releases the data, which the garbage collector frees once no other array shares it.
+// The array is then like a zero Array, and can be allocated again by NewAthenaArray.
+func (this *Array[T]) DeleteAthenaArray() {
+	this.pdata_ = nil
+	this.dim_num = nil
+	this.shallow = false
+}
+
+// Swaps the data and dimensions of the two arrays without copying the data, e.g. to
+// rotate the registers of a multi-stage integrator.
+func (this *Array[T]) SwapAthenaArray(other *Array[T]) {
+	*this, *other = *other, *this
+}
+
+// Changes the dimensions to nx, of the same size, keeping the data in the same order.
+// Shallow slices of the array aren't reshaped.
+func (this *Array[T]) Reshape(nx ...int) error {
+	data_num := 1
+	for _, i := range nx {
+		if i < 0 {
+			return fmt.Errorf("Reshape Array Error: Negative dimension in %v.", nx)
+		}
+		data_num *= i
+	}
+	if len(nx) == 0 || data_num != len(this.pdata_) {
+		return fmt.Errorf("Reshape Array Error: Can't reshape %v of size %d to %v.", this.dim_num, len(this.pdata_), nx)
+	}
+	this.dim_num = make([]int, len(nx))
+	copy(this.dim_num, nx)
+	return nil
 }
 
 func DeepCopyArray[T any](other Array[T]) Array[T] {
@@ -32,8 +83,58 @@ func DeepCopyArray[T any](other Array[T]) Array[T] {
 	return this
 }
 
+// A shallow slice of nvar elements of src from indx along the dimension dim, without
+// copying, like InitWithShallowSlice of Athena++. Dimensions are counted as in GetDim,
+// where dim = 1 is the first (fastest) one given to AthenaArray. The view keeps the
+// dimensions below dim, its dimension dim is nvar, and the dimensions above dim are
+// dropped (their first index is taken). E.g. with u := AthenaArray[float64](nx1, nx2,
+// nx3, NHYDRO), ShallowSliceArray(u, 4, IDN, 1) is the density of shape (nx1, nx2, nx3, 1).
+func ShallowSliceArray[T any](src Array[T], dim int, indx int, nvar int) (Array[T], error) {
+	var this Array[T]
+	ndim := len(src.dim_num)
+	if dim < 1 || dim > ndim {
+		return this, fmt.Errorf("Slice Array Error: Dimension %d is out of range [1, %d].", dim, ndim)
+	}
+	nx := src.dim_num[dim-1]
+	if indx < 0 || nvar < 1 || indx+nvar > nx {
+		return this, fmt.Errorf("Slice Array Error: Elements [%d, %d) are out of range [0, %d) of dimension %d.",
+			indx, indx+nvar, nx, dim)
+	}
+	// The elements of a fixed index along dim are contiguous, with the size of the
+	// dimensions below dim.
+	stride := 1
+	for _, i := range src.dim_num[:dim-1] {
+		stride *= i
+	}
+	begin, end := indx*stride, (indx+nvar)*stride
+	// The capacity is limited, so that an append to the view can't overwrite the source.
+	this.pdata_ = src.pdata_[begin:end:end]
+	this.dim_num = make([]int, dim)
+	copy(this.dim_num, src.dim_num[:dim])
+	this.dim_num[dim-1] = nvar
+	this.shallow = true
+	return this, nil
+}
+
+// A shallow copy of the whole src, sharing its data but not its dimensions. Unlike "=",
+// the result is marked as a shallow slice.
+func ShallowCopyArray[T any](src Array[T]) Array[T] {
+	var this Array[T]
+	this.pdata_ = src.pdata_[:len(src.pdata_):len(src.pdata_)]
+	this.dim_num = make([]int, len(src.dim_num))
+	copy(this.dim_num, src.dim_num)
+	this.shallow = src.pdata_ != nil
+	return this
+}
+
+func (this *Array[T]) IsShallowSlice() bool {
+	return this.shallow
+}
+
+// Dimensions are counted as in Athena++, where dim = 1 is the first (fastest) one given
+// to AthenaArray. It's 0 for a dimension the array doesn't have.
 func (this *Array[T]) GetDim(dim int) int {
-	if dim > len(this.dim_num) {
+	if dim < 1 || dim > len(this.dim_num) {
 		return 0
 	}
 	return this.dim_num[dim-1]
@@ -55,7 +156,10 @@ func (this *Array[T]) GetSize() int {
 }
 
 // If T is a reference type, note that it will be returned with shallow copying. Remember
-// that the first dimention is access using the last parameter as default (in Athena++)
+// that the first dimention is access using the last parameter as default (in Athena++).
+// Get and Set are the checked accessors: the number of indices must equal the number of
+// dimensions and each index must be in [0, size of its dimension), otherwise an error
+// naming the dimension and the index is returned.
 func (this *Array[T]) Get(ij ...int) (T, error) {
 	sum, err := this.access(ij)
 	var temp T // Make sure return the zero value of type T.
@@ -76,21 +180,33 @@ func (this *Array[T]) Set(value T, ij ...int) error {
 
 // It's a private function.
 func (this *Array[T]) access(ij []int) (int, error) {
-	if len(ij) != len(this.dim_num) {
-		panic("Access Array Error: Number of parameters doesn't equal to array dimention.")
+	if err := this.indexError(ij); err != nil {
+		return 0, err
 	}
 	var sum int
 	weight := 1
-	// Change order to make sure it's the same as normal (Athena++) version.
-	for i, j := 0, len(ij)-1; i < j; i, j = i+1, j-1 {
-		ij[i], ij[j] = ij[j], ij[i]
+	// The last parameter is the first (fastest) dimension dim_num[0], as in Athena++.
+	// Parameters are read backwards instead of reversing ij, which is the caller's slice.
+	for i, nx := range this.dim_num {
+		sum += ij[len(ij)-1-i] * weight
+		weight *= nx
+	}
+	return sum, nil
+}
+
+// It's a private function. It returns an error if the indices don't fit the array.
+// Dimensions are counted as in Athena++, from the last index.
+func (this *Array[T]) indexError(ij []int) error {
+	if len(ij) != len(this.dim_num) {
+		return fmt.Errorf("Access Array Error: %d indices are given for a %d-dimensional array.",
+			len(ij), len(this.dim_num))
 	}
 	for i, j := range ij {
-		if j > this.dim_num[i] {
-			return 0, fmt.Errorf("Access Array Error: Parameter %d exceed the limit.", i)
+		nx := this.dim_num[len(ij)-1-i]
+		if j < 0 || j >= nx {
+			return fmt.Errorf("Access Array Error: Index %d of dimension %d is out of range [0, %d).",
+				j, len(ij)-i, nx)
 		}
-		sum += j * weight
-		weight *= this.dim_num[i]
 	}
-	return sum, nil
+	return nil
 }
@@ -0,0 +1,191 @@
+package utils
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// The dimensions of the arrays, given to AthenaArray from the first (fastest) one.
+var testDims = [][]int{
+	{5},
+	{5, 4},
+	{5, 4, 3},
+	{5, 4, 3, 2},
+	{5, 4, 3, 2, 3},
+	{5, 4, 3, 2, 3, 2},
+}
+
+// The flat offset of the indices (in the order of Get) in an array of dims, written out
+// independently of access.
+func testOffset(dims []int, ij []int) int {
+	offset := 0
+	for d := len(dims) - 1; d >= 0; d-- {
+		offset = offset*dims[d] + ij[len(ij)-1-d]
+	}
+	return offset
+}
+
+// Calls f with every valid index of an array of dims, in the order of Get.
+func testEachIndex(dims []int, f func(ij []int)) {
+	ij := make([]int, len(dims))
+	var loop func(i int)
+	loop = func(i int) {
+		if i == len(ij) {
+			f(ij)
+			return
+		}
+		for ij[i] = 0; ij[i] < dims[len(dims)-1-i]; ij[i]++ {
+			loop(i + 1)
+		}
+	}
+	loop(0)
+}
+
+func TestArrayGetSet(t *testing.T) {
+	for _, dims := range testDims {
+		array := AthenaArray[int](dims...)
+		size := 1
+		for _, nx := range dims {
+			size *= nx
+		}
+		if array.GetSize() != size {
+			t.Fatalf("%v: GetSize() = %d, want %d", dims, array.GetSize(), size)
+		}
+		for dim, nx := range dims {
+			if array.GetDim(dim+1) != nx {
+				t.Errorf("%v: GetDim(%d) = %d, want %d", dims, dim+1, array.GetDim(dim+1), nx)
+			}
+		}
+		if array.GetDim(0) != 0 || array.GetDim(len(dims)+1) != 0 {
+			t.Errorf("%v: GetDim of a missing dimension isn't 0", dims)
+		}
+
+		// Every element is set once, at the offset of its indices.
+		seen := make(map[int]bool)
+		testEachIndex(dims, func(ij []int) {
+			offset := testOffset(dims, ij)
+			if seen[offset] {
+				t.Fatalf("%v: offset %d of %v is used twice", dims, offset, ij)
+			}
+			seen[offset] = true
+			if err := array.Set(offset, ij...); err != nil {
+				t.Fatalf("%v: Set(%v) = %v", dims, ij, err)
+			}
+		})
+		if len(seen) != size {
+			t.Fatalf("%v: %d elements are set, want %d", dims, len(seen), size)
+		}
+		for offset, value := range array.pdata_ {
+			if value != offset {
+				t.Fatalf("%v: element %d = %d", dims, offset, value)
+			}
+		}
+		testEachIndex(dims, func(ij []int) {
+			value, err := array.Get(ij...)
+			if err != nil || value != testOffset(dims, ij) {
+				t.Fatalf("%v: Get(%v) = %d, %v, want %d", dims, ij, value, err, testOffset(dims, ij))
+			}
+		})
+	}
+}
+
+func TestArrayIndexErrors(t *testing.T) {
+	for _, dims := range testDims {
+		array := AthenaArray[int](dims...)
+		last := make([]int, len(dims)) // the last element
+		for i := range last {
+			last[i] = dims[len(dims)-1-i] - 1
+		}
+		for i := range last {
+			dim := len(dims) - i
+			tests := []struct {
+				index int
+				msg   string
+			}{
+				{-1, "Index -1 of dimension"},
+				{dims[dim-1], "is out of range"},
+				{dims[dim-1] + 10, "is out of range"},
+			}
+			for _, test := range tests {
+				ij := append([]int{}, last...)
+				ij[i] = test.index
+				if _, err := array.Get(ij...); err == nil || !strings.Contains(err.Error(), test.msg) ||
+					!strings.Contains(err.Error(), fmt.Sprintf("dimension %d ", dim)) {
+					t.Errorf("%v: Get(%v) = %v, want an error about dimension %d", dims, ij, err, dim)
+				}
+				if err := array.Set(1, ij...); err == nil {
+					t.Errorf("%v: Set(%v) = nil, want an error", dims, ij)
+				}
+			}
+		}
+		for _, ij := range [][]int{last[1:], append(append([]int{}, last...), 0), {}} {
+			if _, err := array.Get(ij...); err == nil || !strings.Contains(err.Error(), "indices are given") {
+				t.Errorf("%v: Get(%v) = %v, want an error about the number of indices", dims, ij, err)
+			}
+			if err := array.Set(1, ij...); err == nil {
+				t.Errorf("%v: Set(%v) = nil, want an error", dims, ij)
+			}
+		}
+		for _, value := range array.pdata_ {
+			if value != 0 {
+				t.Fatalf("%v: a failed Set changed the data", dims)
+			}
+		}
+	}
+}
+
+func TestArrayIndexNotMutated(t *testing.T) {
+	array := AthenaArray[int](5, 4, 3)
+	ij := []int{2, 1, 0}
+	if err := array.Set(7, ij...); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := array.Get(ij...); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(ij, []int{2, 1, 0}) {
+		t.Errorf("the indices are changed to %v", ij)
+	}
+	if value, _ := array.Get(2, 1, 0); value != 7 {
+		t.Errorf("Get(2, 1, 0) = %d, want 7", value)
+	}
+}
+
+func TestArrayAt(t *testing.T) {
+	for _, dims := range testDims[:5] {
+		array := AthenaArray[int](dims...)
+		testEachIndex(dims, func(ij []int) {
+			offset := testOffset(dims, ij)
+			switch len(ij) {
+			case 1:
+				array.Set1(offset, ij[0])
+			case 2:
+				array.Set2(offset, ij[0], ij[1])
+			case 3:
+				array.Set3(offset, ij[0], ij[1], ij[2])
+			case 4:
+				array.Set4(offset, ij[0], ij[1], ij[2], ij[3])
+			case 5:
+				array.Set5(offset, ij[0], ij[1], ij[2], ij[3], ij[4])
+			}
+			var value int
+			switch len(ij) {
+			case 1:
+				value = array.At1(ij[0])
+			case 2:
+				value = array.At2(ij[0], ij[1])
+			case 3:
+				value = array.At3(ij[0], ij[1], ij[2])
+			case 4:
+				value = array.At4(ij[0], ij[1], ij[2], ij[3])
+			case 5:
+				value = array.At5(ij[0], ij[1], ij[2], ij[3], ij[4])
+			}
+			if value != offset || array.pdata_[offset] != offset {
+				t.Fatalf("%v: At/Set of %v don't use offset %d", dims, ij, offset)
+			}
+		})
+	}
+}
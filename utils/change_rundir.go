@@ -0,0 +1,27 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+)
+
+//----------------------------------------------------------------------------------------
+//! \fn error ChangeRunDir(pdir string)
+//! \brief creates (if needed) and change to the run directory specified on the command
+//! line with the -d option
+//!
+//! Nothing is done if pdir is empty. Input and restart files are read before calling this,
+//! so only outputs and restart dumps are written relative to the run directory.
+
+func ChangeRunDir(pdir string) error {
+	if pdir == "" {
+		return nil
+	}
+	if err := os.MkdirAll(pdir, 0775); err != nil {
+		return fmt.Errorf("Change Run Directory Error: Cannot create directory '%s': %v", pdir, err)
+	}
+	if err := os.Chdir(pdir); err != nil {
+		return fmt.Errorf("Change Run Directory Error: Cannot cd to directory '%s': %v", pdir, err)
+	}
+	return nil
+}
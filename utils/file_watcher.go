@@ -0,0 +1,69 @@
+package utils
+
+import (
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+//----------------------------------------------------------------------------------------
+// File watcher, polling the modification time and size of a file in the background. Like
+// the signal handler, it only records that the file is modified, and the main loop checks
+// the flag at the end of each cycle. A file which already exists when the watch starts
+// counts as modified, and a missing file is waited for.
+
+type FileWatcher struct {
+	filename string
+	modified int32
+	done     chan struct{}
+}
+
+//----------------------------------------------------------------------------------------
+//! \fn *FileWatcher WatchFile(filename string, interval time.Duration)
+//! \brief starts watching filename, checking it every interval
+
+func WatchFile(filename string, interval time.Duration) *FileWatcher {
+	this := &FileWatcher{filename: filename, done: make(chan struct{})}
+	var last_time time.Time
+	var last_size int64 = -1
+	check := func() {
+		info, err := os.Stat(this.filename)
+		if err != nil {
+			return
+		}
+		if !info.ModTime().Equal(last_time) || info.Size() != last_size {
+			last_time, last_size = info.ModTime(), info.Size()
+			atomic.StoreInt32(&this.modified, 1)
+		}
+	}
+	check()
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				check()
+			case <-this.done:
+				return
+			}
+		}
+	}()
+	return this
+}
+
+//----------------------------------------------------------------------------------------
+//! \fn bool FileWatcher.CheckModified()
+//! \brief returns true once for each modification of the file
+
+func (this *FileWatcher) CheckModified() bool {
+	return atomic.SwapInt32(&this.modified, 0) == 1
+}
+
+//----------------------------------------------------------------------------------------
+//! \fn FileWatcher.Stop()
+//! \brief stops watching the file
+
+func (this *FileWatcher) Stop() {
+	close(this.done)
+}
@@ -43,6 +43,11 @@ func ShowConfig() {
 		fmt.Println("  Super-Time-Stepping:        OFF")
 	}
 	// configure.py output: +"Debug flags"
+	if ARRAY_BOUNDS_CHECK {
+		fmt.Println("  Array bounds checks:        ON")
+	} else {
+		fmt.Println("  Array bounds checks:        OFF")
+	}
 	// configure.py output: +"Code coverage flags"
 	// configure.py output: +"Linker flags"
 	if SINGLE_PRECISION_ENABLED {
@@ -0,0 +1,102 @@
+package utils
+
+import (
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+//----------------------------------------------------------------------------------------
+// Signal handler functions, the same as the SignalHandler namespace in Athena++.
+//
+// The handler only records which signal is received. The main loop checks the flags at
+// the end of each cycle, so the run can finish its cycle cleanly, write final outputs
+// and a restart dump. SIGALRM is replaced by a timer for the wall-time limit, and SIGUSR1
+// asks for an immediate restart dump without stopping the run.
+
+const (
+	ITERM = iota // SIGTERM
+	IINT         // SIGINT
+	IALRM        // wall-time limit
+	IUSR1        // SIGUSR1
+	nsignal
+)
+
+var signalflag [nsignal]int32
+var wtalarm *time.Timer
+
+//----------------------------------------------------------------------------------------
+//! \fn SignalHandlerInit()
+//! \brief install handlers for SIGTERM, SIGINT and SIGUSR1
+
+func SignalHandlerInit() {
+	for i := range signalflag {
+		atomic.StoreInt32(&signalflag[i], 0)
+	}
+	ch := make(chan os.Signal, nsignal)
+	signal.Notify(ch, append([]os.Signal{syscall.SIGTERM, syscall.SIGINT}, usr1Signals...)...)
+	go func() {
+		for sig := range ch {
+			switch sig {
+			case syscall.SIGTERM:
+				SetSignalFlag(ITERM)
+			case syscall.SIGINT:
+				SetSignalFlag(IINT)
+			default:
+				SetSignalFlag(IUSR1)
+			}
+		}
+	}()
+}
+
+//----------------------------------------------------------------------------------------
+//! \fn bool CheckSignalFlags()
+//! \brief returns true if the run should be terminated (SIGTERM, SIGINT or wall time)
+
+func CheckSignalFlags() bool {
+	return GetSignalFlag(ITERM) || GetSignalFlag(IINT) || GetSignalFlag(IALRM)
+}
+
+//----------------------------------------------------------------------------------------
+//! \fn bool GetSignalFlag(s int)
+//! \brief gets a signal flag assuming the signalflag array is already synchronized.
+
+func GetSignalFlag(s int) bool {
+	return atomic.LoadInt32(&signalflag[s]) != 0
+}
+
+//----------------------------------------------------------------------------------------
+//! \fn SetSignalFlag(s int)
+//! \brief sets signal flags
+
+func SetSignalFlag(s int) {
+	atomic.StoreInt32(&signalflag[s], 1)
+}
+
+//----------------------------------------------------------------------------------------
+//! \fn bool CheckRestartFlag()
+//! \brief returns true (once) if SIGUSR1 is received since the last call
+
+func CheckRestartFlag() bool {
+	return atomic.SwapInt32(&signalflag[IUSR1], 0) != 0
+}
+
+//----------------------------------------------------------------------------------------
+//! \fn SetWallTimeAlarm(t time.Duration)
+//! \brief set the wall time limit alarm
+
+func SetWallTimeAlarm(t time.Duration) {
+	wtalarm = time.AfterFunc(t, func() { SetSignalFlag(IALRM) })
+}
+
+//----------------------------------------------------------------------------------------
+//! \fn CancelWallTimeAlarm()
+//! \brief cancel the wall time limit alarm
+
+func CancelWallTimeAlarm() {
+	if wtalarm != nil {
+		wtalarm.Stop()
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !windows
+
+package utils
+
+import (
+	"os"
+	"syscall"
+)
+
+var usr1Signals = []os.Signal{syscall.SIGUSR1}
@@ -0,0 +1,6 @@
+package utils
+
+import "os"
+
+// There is no SIGUSR1 on Windows.
+var usr1Signals = []os.Signal{}